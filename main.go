@@ -1,31 +1,41 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"interpreters/pkg/pyro"
 )
 
-var hasError bool
+const usage = `Usage: goPyro [--vm] [script]
+       goPyro compile <script> [-o <output>]`
 
 func main() {
-	// Create a reader to read input from the user
-	args := os.Args
-	hasError = false
-	for _, arg := range args {
-		fmt.Println(arg)
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "compile" {
+		compileFile(args[1:])
+		return
+	}
+
+	useVM := false
+	if len(args) > 0 && args[0] == "--vm" {
+		useVM = true
+		args = args[1:]
 	}
-	if len(args) > 2 {
-		fmt.Println("Usage: goPyro [script]")
+
+	if len(args) > 1 {
+		fmt.Println(usage)
 		return
-	} else if len(args) == 2 {
-		runFile(args[1])
+	} else if len(args) == 1 {
+		runFile(args[0], useVM)
 	} else {
-		runPrompt()
+		runPrompt(useVM)
 	}
 
 }
-func runFile(fileName string) {
+func runFile(fileName string, useVM bool) {
 	fileBytes, err := os.ReadFile(fileName)
 
 	if err != nil {
@@ -33,39 +43,66 @@ func runFile(fileName string) {
 		return
 	}
 
-	run(string(fileBytes))
-}
-func runPrompt() {
-	input := bufio.NewReader(os.Stdin)
-	for {
-		hasError = false
-		line, err := input.ReadString('\n')
-		if err != nil {
-			fmt.Println("Error reading line:", err)
-			return
+	engine := pyro.NewEngine()
+	engine.UseVM(useVM)
+	if pyro.IsCompiled(fileBytes) {
+		_, err = engine.EvalCompiled(fileBytes)
+		if _, isFormat := err.(pyro.FormatError); isFormat {
+			fmt.Fprintln(os.Stderr, fileName+":", err)
+			os.Exit(65)
 		}
-		if line == "" {
-			break
-		}
-		run(line)
-
+		exitOnError(err)
+		return
 	}
+	exitOnError(run(engine, fileName, string(fileBytes)))
 }
 
-func run(source string) {
-	scanner := NewScanner(source)
-	tokens := scanner.scanTokens()
-	// fmt.Println(tokens)
-	parser := Parser{tokens, 0}
-	statements, _ := parser.parse()
-	// fmt.Println(statements)
-	interpreter := Interpreter{
-		Environment: NewEnvironment(),
+// compileFile implements "compile <script> [-o <output>]", writing the
+// parsed program next to the script as .pyroc unless told otherwise.
+func compileFile(args []string) {
+	if len(args) != 1 && (len(args) != 3 || args[1] != "-o") {
+		fmt.Println(usage)
+		return
+	}
+	fileName := args[0]
+	output := strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".pyroc"
+	if len(args) == 3 {
+		output = args[2]
 	}
-	interpreter.interpret(statements)
 
-	if hasError {
-		os.Exit(65)
+	fileBytes, err := os.ReadFile(fileName)
+	if err != nil {
+		fmt.Println("Error opening file:", err)
+		return
 	}
 
+	compiled, err := pyro.NewEngine().Compile(fileName, string(fileBytes))
+	exitOnError(err)
+
+	err = os.WriteFile(output, compiled, 0644)
+	if err != nil {
+		fmt.Println("Error writing file:", err)
+		os.Exit(74)
+	}
+}
+func runPrompt(useVM bool) {
+	NewRepl(os.Stdin, os.Stdout, useVM).run()
+}
+
+func run(engine *pyro.Engine, name string, source string) error {
+	_, err := engine.EvalNamed(name, source)
+	return err
+}
+
+func exitOnError(err error) {
+	if err == nil {
+		return
+	}
+	if exitErr, isExit := err.(pyro.ExitError); isExit {
+		os.Exit(exitErr.Code)
+	}
+	if err == pyro.ErrCompile {
+		os.Exit(65)
+	}
+	os.Exit(70)
 }
@@ -0,0 +1,232 @@
+package pyro
+
+import (
+	"strings"
+)
+
+// AstPrinter renders syntax trees as Lisp-style S-expressions, which is handy
+// for checking how the parser grouped an expression.
+type AstPrinter struct {
+	out *strings.Builder
+}
+
+func (a AstPrinter) Print(expr Expr) string {
+	str, _ := expr.Accept(a)
+	return str.(string)
+}
+
+func (a AstPrinter) PrintStmt(stmt Stmt) string {
+	printer := AstPrinter{out: &strings.Builder{}}
+	stmt.Accept(printer)
+	return printer.out.String()
+}
+
+func (a AstPrinter) parenthesize(name string, parts ...string) string {
+	return "(" + strings.Join(append([]string{name}, parts...), " ") + ")"
+}
+
+func (a AstPrinter) stmts(statements []Stmt) []string {
+	parts := make([]string, 0, len(statements))
+	for _, statement := range statements {
+		parts = append(parts, a.PrintStmt(statement))
+	}
+	return parts
+}
+
+func (a AstPrinter) VisitVariableExpr(expr Variable) (interface{}, error) {
+	return expr.Name.Lexeme, nil
+}
+
+func (a AstPrinter) VisitAssignExpr(expr Assign) (interface{}, error) {
+	return a.parenthesize("=", expr.Name.Lexeme, a.Print(expr.Value)), nil
+}
+
+func (a AstPrinter) VisitBinaryExpr(expr Binary) (interface{}, error) {
+	return a.parenthesize(expr.Operator.Lexeme, a.Print(expr.Left), a.Print(expr.Right)), nil
+}
+
+func (a AstPrinter) VisitLogicalExpr(expr Logical) (interface{}, error) {
+	return a.parenthesize(expr.Operator.Lexeme, a.Print(expr.Left), a.Print(expr.Right)), nil
+}
+
+func (a AstPrinter) VisitConditionalExpr(expr Conditional) (interface{}, error) {
+	return a.parenthesize("?:", a.Print(expr.Condition), a.Print(expr.ThenBranch), a.Print(expr.ElseBranch)), nil
+}
+
+func (a AstPrinter) VisitCoalesceExpr(expr Coalesce) (interface{}, error) {
+	return a.parenthesize(expr.Operator.Lexeme, a.Print(expr.Left), a.Print(expr.Right)), nil
+}
+
+func (a AstPrinter) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, error) {
+	switch {
+	case expr.Postfix:
+		return a.parenthesize("post"+expr.Operator.Lexeme, a.Print(expr.Target)), nil
+	case expr.Operator.Type == PLUSPLUS || expr.Operator.Type == MINUSMINUS:
+		return a.parenthesize(expr.Operator.Lexeme, a.Print(expr.Target)), nil
+	}
+	return a.parenthesize(expr.Operator.Lexeme, a.Print(expr.Target), a.Print(expr.Value)), nil
+}
+
+func (a AstPrinter) VisitUnaryExpr(expr Unary) (interface{}, error) {
+	return a.parenthesize(expr.Operator.Lexeme, a.Print(expr.Right)), nil
+}
+
+func (a AstPrinter) VisitLiteralExpr(expr Literal) (interface{}, error) {
+	if str, isStr := expr.Value.(string); isStr {
+		return "\"" + str + "\"", nil
+	}
+	if num, isDecimal := expr.Value.(*PyroDecimal); isDecimal {
+		return num.String() + "d", nil
+	}
+	return stringify(expr.Value), nil
+}
+
+func (a AstPrinter) VisitGroupingExpr(expr Grouping) (interface{}, error) {
+	return a.parenthesize("group", a.Print(expr.Expression)), nil
+}
+
+func (a AstPrinter) VisitCallExpr(expr Call) (interface{}, error) {
+	parts := []string{a.Print(expr.Callee)}
+	for _, argument := range expr.Arguments {
+		parts = append(parts, a.Print(argument))
+	}
+	return a.parenthesize("call", parts...), nil
+}
+
+func (a AstPrinter) VisitGetExpr(expr Get) (interface{}, error) {
+	return a.parenthesize(".", a.Print(expr.Object), expr.Name.Lexeme), nil
+}
+
+func (a AstPrinter) VisitSetExpr(expr Set) (interface{}, error) {
+	return a.parenthesize("=", a.parenthesize(".", a.Print(expr.Object), expr.Name.Lexeme), a.Print(expr.Value)), nil
+}
+func (a AstPrinter) VisitListExpr(expr List) (interface{}, error) {
+	parts := make([]string, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		parts = append(parts, a.Print(element))
+	}
+	return a.parenthesize("list", parts...), nil
+}
+func (a AstPrinter) VisitMapExpr(expr Map) (interface{}, error) {
+	parts := make([]string, 0, len(expr.Keys))
+	for i := range expr.Keys {
+		parts = append(parts, a.parenthesize(":", a.Print(expr.Keys[i]), a.Print(expr.Values[i])))
+	}
+	return a.parenthesize("map", parts...), nil
+}
+func (a AstPrinter) VisitInterpolationExpr(expr Interpolation) (interface{}, error) {
+	parts := make([]string, 0, len(expr.Parts))
+	for _, part := range expr.Parts {
+		parts = append(parts, a.Print(part))
+	}
+	return a.parenthesize("interpolate", parts...), nil
+}
+func (a AstPrinter) VisitLambdaExpr(expr Lambda) (interface{}, error) {
+	params := make([]string, 0, len(expr.Declaration.Params))
+	for _, param := range expr.Declaration.Params {
+		params = append(params, param.Lexeme)
+	}
+	parts := append([]string{"(" + strings.Join(params, " ") + ")"}, a.stmts(expr.Declaration.Body)...)
+	return a.parenthesize("fun", parts...), nil
+}
+func (a AstPrinter) VisitSubscriptExpr(expr Subscript) (interface{}, error) {
+	return a.parenthesize("[]", a.Print(expr.Object), a.Print(expr.Index)), nil
+}
+func (a AstPrinter) VisitSubscriptSetExpr(expr SubscriptSet) (interface{}, error) {
+	return a.parenthesize("=", a.parenthesize("[]", a.Print(expr.Object), a.Print(expr.Index)), a.Print(expr.Value)), nil
+}
+
+func (a AstPrinter) VisitThisExpr(expr This) (interface{}, error) {
+	return "this", nil
+}
+
+func (a AstPrinter) VisitSuperExpr(expr Super) (interface{}, error) {
+	return a.parenthesize("super", expr.Method.Lexeme), nil
+}
+
+func (a AstPrinter) VisitExpressionStmt(stmt Expression) error {
+	a.out.WriteString(a.parenthesize(";", a.Print(stmt.Expression)))
+	return nil
+}
+
+func (a AstPrinter) VisitPrintStmt(stmt Print) error {
+	a.out.WriteString(a.parenthesize("print", a.Print(stmt.Expression)))
+	return nil
+}
+
+func (a AstPrinter) VisitVarStmt(stmt Var) error {
+	if stmt.Initalizer == nil {
+		a.out.WriteString(a.parenthesize("var", stmt.Name.Lexeme))
+		return nil
+	}
+	a.out.WriteString(a.parenthesize("var", stmt.Name.Lexeme, a.Print(*stmt.Initalizer)))
+	return nil
+}
+
+func (a AstPrinter) VisitBlockStmt(stmt Block) error {
+	a.out.WriteString(a.parenthesize("block", a.stmts(stmt.Statements)...))
+	return nil
+}
+
+func (a AstPrinter) VisitIfStmt(stmt If) error {
+	parts := []string{a.Print(stmt.Condition), a.PrintStmt(stmt.ThenBranch)}
+	if stmt.ElseBranch != nil {
+		parts = append(parts, a.PrintStmt(*stmt.ElseBranch))
+	}
+	a.out.WriteString(a.parenthesize("if", parts...))
+	return nil
+}
+
+func (a AstPrinter) VisitWhileStmt(stmt While) error {
+	if stmt.Increment != nil {
+		a.out.WriteString(a.parenthesize("while", a.Print(stmt.Condition), a.PrintStmt(stmt.Body), a.Print(*stmt.Increment)))
+		return nil
+	}
+	a.out.WriteString(a.parenthesize("while", a.Print(stmt.Condition), a.PrintStmt(stmt.Body)))
+	return nil
+}
+
+func (a AstPrinter) VisitBreakStmt(stmt Break) error {
+	a.out.WriteString("(break)")
+	return nil
+}
+
+func (a AstPrinter) VisitContinueStmt(stmt Continue) error {
+	a.out.WriteString("(continue)")
+	return nil
+}
+
+func (a AstPrinter) function(keyword string, stmt Function) string {
+	params := make([]string, 0, len(stmt.Params))
+	for _, param := range stmt.Params {
+		params = append(params, param.Lexeme)
+	}
+	parts := append([]string{stmt.Name.Lexeme, "(" + strings.Join(params, " ") + ")"}, a.stmts(stmt.Body)...)
+	return a.parenthesize(keyword, parts...)
+}
+
+func (a AstPrinter) VisitFunctionStmt(stmt Function) error {
+	a.out.WriteString(a.function("fun", stmt))
+	return nil
+}
+
+func (a AstPrinter) VisitReturnStmt(stmt Return) error {
+	if stmt.Value == nil {
+		a.out.WriteString("(return)")
+		return nil
+	}
+	a.out.WriteString(a.parenthesize("return", a.Print(*stmt.Value)))
+	return nil
+}
+
+func (a AstPrinter) VisitClassStmt(stmt Class) error {
+	parts := []string{stmt.Name.Lexeme}
+	if stmt.Superclass != nil {
+		parts = append(parts, "<", stmt.Superclass.Name.Lexeme)
+	}
+	for _, method := range stmt.Methods {
+		parts = append(parts, a.function("method", method))
+	}
+	a.out.WriteString(a.parenthesize("class", parts...))
+	return nil
+}
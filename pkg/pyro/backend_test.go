@@ -0,0 +1,331 @@
+package pyro
+
+import "testing"
+
+// backendTests are run on both the tree-walker and the VM, which must print
+// the same thing. An error ends the output with "error: " and its message.
+var backendTests = []struct {
+	name   string
+	source string
+	want   string
+}{
+	{
+		"lists",
+		`var xs = [3, 1, 2];
+xs.push(4);
+print xs;
+print xs.pop();
+xs.insert(0, 9);
+print xs;
+print xs.remove(1);
+print xs[-1];
+print xs.slice(1);
+print xs.slice(0, -1);
+print xs.map((x) => x * 2);
+print xs.filter((x) => x > 2);
+print xs.reduce((a, b) => a + b);
+print xs.reduce((a, b) => a + b, 100);
+xs.sort();
+print xs;
+var words = ["pear", "fig", "apple"];
+words.sort((a, b) => len(a) - len(b));
+print words;
+var ys = xs;
+ys[0] = 0;
+print xs;
+print len([]);
+`,
+		`[3, 1, 2, 4]
+4
+[9, 3, 1, 2]
+3
+2
+[1, 2]
+[9, 1]
+[18, 2, 4]
+[9]
+12
+112
+[1, 2, 9]
+["fig", "pear", "apple"]
+[0, 2, 9]
+0
+`,
+	},
+	{
+		"maps",
+		`var m = {"b": 2, "a": 1};
+m["c"] = 3;
+m[1] = "one";
+m[1.0] = "uno";
+print m;
+print m.has("a");
+print m.get("z", "none");
+print m.delete("b");
+print m.keys();
+print m.values();
+print m.items();
+print len(m);
+m.each(fun (k, v) { print str(k) + ":" + str(v); });
+`,
+		`{"b": 2, "a": 1, "c": 3, 1: "uno"}
+true
+none
+true
+["a", "c", 1]
+[1, 3, "uno"]
+[["a", 1], ["c", 3], [1, "uno"]]
+3
+a:1
+c:3
+1:uno
+`,
+	},
+	{
+		"closures",
+		`fun counter() {
+  var n = 0;
+  return fun () { n++; return n; };
+}
+var a = counter();
+var b = counter();
+print a(); print a(); print b();
+var fs = [];
+for (var i = 0; i < 3; i++) {
+  var j = i;
+  fs.push(() => j);
+}
+print fs.map((f) => f());
+fun outer() {
+  var x = "before";
+  fun show() { return x; }
+  x = "after";
+  return show;
+}
+print outer()();
+var adder = (a) => (b) => a + b;
+print adder(2)(3);
+`,
+		`1
+2
+1
+[0, 1, 2]
+after
+5
+`,
+	},
+	{
+		"classes and inheritance",
+		`class Animal {
+  init(name) { this.name = name; }
+  speak() { return this.name + " makes a sound"; }
+}
+class Dog < Animal {
+  init(name) { super.init(name); this.tricks = 0; }
+  speak() { return super.speak() + ": woof"; }
+  learn() { this.tricks++; return this; }
+}
+var d = Dog("Rex");
+print d.speak();
+print d.learn().learn().tricks;
+var speak = d.speak;
+print speak();
+print type(d);
+print type(Dog);
+print d;
+print Dog;
+class Counter { init() { this.n = 0; } }
+var c = Counter();
+c.n += 5;
+c.n++;
+print c.n;
+`,
+		`Rex makes a sound: woof
+2
+Rex makes a sound: woof
+instance
+class
+Dog instance
+Dog
+6
+`,
+	},
+	{
+		"control flow and strings",
+		`var total = 0;
+for (var i = 0; i < 10; i++) {
+  if (i % 2 == 0) continue;
+  if (i > 7) break;
+  total += i;
+}
+print total;
+var n = 0;
+while (true) { n++; if (n >= 3) break; }
+print n;
+print nil ?? "default";
+print false ?? "kept";
+print 1 < 2 ? "yes" : "no";
+print nil or "fallback";
+print 0 and "never";
+var s = "a";
+s += "b";
+print s;
+print "${1 + 2} and ${[1, "x"]}";
+print """
+  two
+    lines
+  """;
+print r"C:\n";
+print len("héllo");
+var i = 5;
+print i++;
+print i;
+print ++i;
+print i--;
+print --i;
+`,
+		`16
+3
+default
+false
+yes
+fallback
+never
+ab
+3 and [1, "x"]
+two
+  lines
+C:\n
+5
+5
+6
+7
+7
+5
+`,
+	},
+	{
+		"integer, float and decimal arithmetic",
+		`print -7 % 2; print 7 % -2; print -7 // 2; print 7 // -2;
+print -7.5 % 2; print 7.5 % -2; print -7.5 // 2;
+print -7d % 2; print 7.5d % -2; print -7.5d // 2;
+print -0.0 % 5;
+print 5 % 3 == 5 - (5 // 3) * 3;
+print (-9223372036854775807 - 1) % -1;
+print 18446744073709551616 % -7;
+print -18446744073709551616 // 7;
+print 7 / 2; print 2 ** 10; print 2 ** -1; print 9223372036854775807 + 1;
+print 0.1 + 0.2; print 0.1d + 0.2d; print 12.50d; print 10.00d / 4;
+print 1 == 1.0; print 1d == 1; print 0xFF + 0b1010 + 0o17; print 1_000_000;
+print 6 & 3; print 6 | 3; print 6 ^ 3; print ~6; print 1 << 70; print -16 >> 2;
+`,
+		`1
+-1
+-4
+-4
+0.5
+-0.5
+-4.0
+1
+-0.5
+-4
+0.0
+true
+0
+-5
+-2635249153387078803
+3.5
+1024
+0.5
+9223372036854775808
+0.30000000000000004
+0.3
+12.50
+2.50
+true
+true
+280
+1000000
+2
+7
+5
+-7
+1180591620717411303424
+-4
+`,
+	},
+	{
+		"rounding and the decimal context",
+		`var subtotal = 3 * 2.675d;
+print subtotal;
+print round(subtotal, 2);
+print round(subtotal, 2, "half-up");
+print round(subtotal, 2, "half-down");
+print round(subtotal, 0, "ceiling");
+print round(subtotal, 0, "floor");
+print round(-2.5d, 0, "up");
+print round(-2.5d, 0, "down");
+print round(2.5);
+print round(3.5);
+print round(1250, -2);
+print round(1350, -2);
+print round(2.5d, 2);
+print 1d / 3;
+print 2d ** -1;
+decimal_context(5, "half-up");
+print 2d / 3;
+print 1d / 8;
+print 3d ** -2;
+decimal_context(3, "floor");
+print -1d / 3;
+decimal_context(3, "ceiling");
+print -1d / 3;
+var x = 1d;
+x /= 7;
+print x;
+`,
+		`8.025
+8.02
+8.03
+8.02
+9
+8
+-3
+-2
+2.0
+4.0
+1200
+1400
+2.50
+0.3333333333333333333333333333333333
+0.5
+0.66667
+0.125
+0.11111
+-0.334
+-0.333
+0.143
+`,
+	},
+	{"division by zero", "print 1 % 0;", "error: Division by zero\n"},
+	{"undefined variable", "print 1; print missing;", "1\nerror: Undefined variable 'missing'.\n"},
+	{"wrong argument count", "fun f(a) { return a; } f(1, 2);", "error: Expected 1 arguments but got 2\n"},
+	{"calling a number", "var x = 1; x();", "error: Can only call functions and classes.\n"},
+	{"mixing decimal and float", "print 1d + 1.5;", "error: Can't mix decimal and float operands; convert one with decimal() or float()\n"},
+	{"sort comparator that empties the list", "var xs = [3, 2, 1, 5, 4, 9, 8, 7, 6, 10, 12, 11, 13, 15, 14];\nxs.sort(fun (a, b) { xs.pop(); return a - b; });", "error: Can't pop from an empty list.\n"},
+	{"bad decimal context", "decimal_context(0, \"half-up\");", "error: Decimal digits must be between 1 and 16777216.\n"},
+	{"unknown rounding mode", "print round(1.5, 0, \"sideways\");", "error: Unknown rounding mode 'sideways'; expected one of half-even, half-up, half-down, up, down, ceiling, floor.\n"},
+}
+
+func TestBackendsAgree(t *testing.T) {
+	for _, test := range backendTests {
+		tree := runScript(test.source, false)
+		vm := runScript(test.source, true)
+		if tree != test.want {
+			t.Errorf("%s: tree-walker printed\n%s\nwant\n%s", test.name, tree, test.want)
+		}
+		if vm != tree {
+			t.Errorf("%s: VM printed\n%s\ntree-walker printed\n%s", test.name, vm, tree)
+		}
+	}
+}
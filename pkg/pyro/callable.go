@@ -1,4 +1,4 @@
-package main
+package pyro
 
 type Callable interface {
 	Arity() int
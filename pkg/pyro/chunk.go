@@ -0,0 +1,269 @@
+package pyro
+
+import (
+	"fmt"
+	"strings"
+)
+
+type OpCode byte
+
+const (
+	OP_CONSTANT OpCode = iota // u16 constant index
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	OP_DUP           // u8 count of values to copy from the top
+	OP_BURY          // u8 depth to move the top value down to
+	OP_GET_LOCAL     // u8 slot
+	OP_SET_LOCAL     // u8 slot
+	OP_STORE_LOCAL   // u8 slot; like OP_SET_LOCAL but pops the value
+	OP_GET_GLOBAL    // u16 name constant
+	OP_DEFINE_GLOBAL // u16 name constant
+	OP_SET_GLOBAL    // u16 name constant
+	OP_STORE_GLOBAL  // u16 name constant; like OP_SET_GLOBAL but pops the value
+	OP_GET_UPVALUE   // u8 upvalue index
+	OP_SET_UPVALUE   // u8 upvalue index
+	OP_GET_PROPERTY  // u16 name constant
+	OP_SET_PROPERTY  // u16 name constant
+	OP_GET_SUPER     // u16 name constant
+	OP_LIST          // u16 element count
+	OP_MAP           // u16 entry count
+	OP_INTERPOLATE   // u16 part count
+	OP_GET_INDEX
+	OP_SET_INDEX
+	OP_UNARY           // u8 operator TokenType
+	OP_BINARY          // u8 operator TokenType
+	OP_BINARY_CONSTANT // u8 arithmetic TokenType, u16 constant right operand
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_MODULO
+	OP_EQUAL
+	OP_NOT_EQUAL
+	OP_GREATER
+	OP_GREATER_EQUAL
+	OP_LESS
+	OP_LESS_EQUAL
+	OP_PRINT
+	OP_JUMP                  // u16 forward offset
+	OP_JUMP_IF_FALSE         // u16 forward offset
+	OP_POP_JUMP_IF_FALSE     // u16 forward offset
+	OP_JUMP_IF_NOT_NIL       // u16 forward offset
+	OP_COMPARE_JUMP          // u8 comparison TokenType, u16 forward offset taken if it is false
+	OP_COMPARE_CONSTANT_JUMP // like OP_COMPARE_JUMP with a u16 constant right operand before the offset
+	OP_LOOP                  // u16 backward offset
+	OP_CALL                  // u8 argument count
+	OP_CLOSURE               // u16 function constant, then (u8 isLocal, u8 index) per upvalue
+	OP_CLOSE_UPVALUE
+	OP_RETURN
+	OP_CLASS // u16 name constant
+	OP_INHERIT
+	OP_METHOD // u16 name constant
+)
+
+var opNames = [...]string{
+	OP_CONSTANT:              "OP_CONSTANT",
+	OP_NIL:                   "OP_NIL",
+	OP_TRUE:                  "OP_TRUE",
+	OP_FALSE:                 "OP_FALSE",
+	OP_POP:                   "OP_POP",
+	OP_DUP:                   "OP_DUP",
+	OP_BURY:                  "OP_BURY",
+	OP_GET_LOCAL:             "OP_GET_LOCAL",
+	OP_SET_LOCAL:             "OP_SET_LOCAL",
+	OP_STORE_LOCAL:           "OP_STORE_LOCAL",
+	OP_GET_GLOBAL:            "OP_GET_GLOBAL",
+	OP_DEFINE_GLOBAL:         "OP_DEFINE_GLOBAL",
+	OP_SET_GLOBAL:            "OP_SET_GLOBAL",
+	OP_STORE_GLOBAL:          "OP_STORE_GLOBAL",
+	OP_GET_UPVALUE:           "OP_GET_UPVALUE",
+	OP_SET_UPVALUE:           "OP_SET_UPVALUE",
+	OP_GET_PROPERTY:          "OP_GET_PROPERTY",
+	OP_SET_PROPERTY:          "OP_SET_PROPERTY",
+	OP_GET_SUPER:             "OP_GET_SUPER",
+	OP_LIST:                  "OP_LIST",
+	OP_MAP:                   "OP_MAP",
+	OP_INTERPOLATE:           "OP_INTERPOLATE",
+	OP_GET_INDEX:             "OP_GET_INDEX",
+	OP_SET_INDEX:             "OP_SET_INDEX",
+	OP_UNARY:                 "OP_UNARY",
+	OP_BINARY:                "OP_BINARY",
+	OP_BINARY_CONSTANT:       "OP_BINARY_CONSTANT",
+	OP_ADD:                   "OP_ADD",
+	OP_SUBTRACT:              "OP_SUBTRACT",
+	OP_MULTIPLY:              "OP_MULTIPLY",
+	OP_DIVIDE:                "OP_DIVIDE",
+	OP_MODULO:                "OP_MODULO",
+	OP_EQUAL:                 "OP_EQUAL",
+	OP_NOT_EQUAL:             "OP_NOT_EQUAL",
+	OP_GREATER:               "OP_GREATER",
+	OP_GREATER_EQUAL:         "OP_GREATER_EQUAL",
+	OP_LESS:                  "OP_LESS",
+	OP_LESS_EQUAL:            "OP_LESS_EQUAL",
+	OP_PRINT:                 "OP_PRINT",
+	OP_JUMP:                  "OP_JUMP",
+	OP_JUMP_IF_FALSE:         "OP_JUMP_IF_FALSE",
+	OP_POP_JUMP_IF_FALSE:     "OP_POP_JUMP_IF_FALSE",
+	OP_JUMP_IF_NOT_NIL:       "OP_JUMP_IF_NOT_NIL",
+	OP_COMPARE_JUMP:          "OP_COMPARE_JUMP",
+	OP_COMPARE_CONSTANT_JUMP: "OP_COMPARE_CONSTANT_JUMP",
+	OP_LOOP:                  "OP_LOOP",
+	OP_CALL:                  "OP_CALL",
+	OP_CLOSURE:               "OP_CLOSURE",
+	OP_CLOSE_UPVALUE:         "OP_CLOSE_UPVALUE",
+	OP_RETURN:                "OP_RETURN",
+	OP_CLASS:                 "OP_CLASS",
+	OP_INHERIT:               "OP_INHERIT",
+	OP_METHOD:                "OP_METHOD",
+}
+
+// binaryOpcodes maps the common binary operators to dedicated instructions
+// with an inline fast path for numbers. Other operators use OP_BINARY.
+var binaryOpcodes = map[TokenType]OpCode{
+	PLUS:  OP_ADD,
+	MINUS: OP_SUBTRACT,
+	STAR:  OP_MULTIPLY,
+	SLASH: OP_DIVIDE,
+	MOD:   OP_MODULO,
+	EQEQ:  OP_EQUAL,
+	NE:    OP_NOT_EQUAL,
+	GT:    OP_GREATER,
+	GE:    OP_GREATER_EQUAL,
+	LT:    OP_LESS,
+	LE:    OP_LESS_EQUAL,
+}
+
+func (op OpCode) String() string {
+	if int(op) < len(opNames) && opNames[op] != "" {
+		return opNames[op]
+	}
+	return fmt.Sprintf("OP_UNKNOWN(%d)", byte(op))
+}
+
+// Chunk is a compiled sequence of bytecode. Spans runs parallel to Code and
+// records the source location each byte was compiled from.
+type Chunk struct {
+	Code      []byte
+	Constants []interface{}
+	Spans     []Span
+
+	constantIndex map[interface{}]int
+	values        []vmValue
+}
+
+func NewChunk() *Chunk {
+	return &Chunk{
+		Code:          make([]byte, 0),
+		Constants:     make([]interface{}, 0),
+		Spans:         make([]Span, 0),
+		constantIndex: make(map[interface{}]int),
+	}
+}
+
+func (c *Chunk) write(b byte, span Span) {
+	c.Code = append(c.Code, b)
+	c.Spans = append(c.Spans, span)
+}
+
+// addConstant returns the index of value in the constant pool, reusing an
+// existing entry for equal strings and numbers. Integers and floats are
+// different keys, so 1 and 1.0 get separate entries.
+func (c *Chunk) addConstant(value interface{}) int {
+	switch value.(type) {
+	case string, int64, float64, *Global:
+		if index, exists := c.constantIndex[value]; exists {
+			return index
+		}
+		c.constantIndex[value] = len(c.Constants)
+	}
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// constantValues returns the constant pool in the VM's stack representation.
+func (c *Chunk) constantValues() []vmValue {
+	if len(c.values) != len(c.Constants) {
+		c.values = make([]vmValue, len(c.Constants))
+		for i, constant := range c.Constants {
+			c.values[i] = toVM(constant)
+		}
+	}
+	return c.values
+}
+
+func (c *Chunk) readShort(offset int) int {
+	return int(c.Code[offset])<<8 | int(c.Code[offset+1])
+}
+
+// disassemble lists every instruction in the chunk, one per line.
+func (c *Chunk) disassemble(name string) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "== %s ==\n", name)
+	for offset := 0; offset < len(c.Code); {
+		offset = c.disassembleInstruction(&out, offset)
+	}
+	return out.String()
+}
+
+func (c *Chunk) disassembleInstruction(out *strings.Builder, offset int) int {
+	fmt.Fprintf(out, "%04d %4d ", offset, c.Spans[offset].Line)
+
+	op := OpCode(c.Code[offset])
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL, OP_STORE_GLOBAL, OP_GET_PROPERTY,
+		OP_SET_PROPERTY, OP_GET_SUPER, OP_CLASS, OP_METHOD:
+		index := c.readShort(offset + 1)
+		constant := c.Constants[index]
+		if global, isGlobal := constant.(*Global); isGlobal {
+			constant = global.Name
+		}
+		fmt.Fprintf(out, "%-16s %4d '%s'\n", op, index, stringify(constant))
+		return offset + 3
+	case OP_DUP, OP_BURY, OP_GET_LOCAL, OP_SET_LOCAL, OP_STORE_LOCAL, OP_GET_UPVALUE, OP_SET_UPVALUE, OP_CALL:
+		fmt.Fprintf(out, "%-16s %4d\n", op, c.Code[offset+1])
+		return offset + 2
+	case OP_LIST, OP_MAP, OP_INTERPOLATE:
+		fmt.Fprintf(out, "%-16s %4d\n", op, c.readShort(offset+1))
+		return offset + 3
+	case OP_UNARY, OP_BINARY:
+		fmt.Fprintf(out, "%-16s %4v\n", op, TokenType(c.Code[offset+1]))
+		return offset + 2
+	case OP_JUMP, OP_JUMP_IF_FALSE, OP_POP_JUMP_IF_FALSE, OP_JUMP_IF_NOT_NIL:
+		fmt.Fprintf(out, "%-16s %4d -> %d\n", op, offset, offset+3+c.readShort(offset+1))
+		return offset + 3
+	case OP_BINARY_CONSTANT:
+		index := c.readShort(offset + 2)
+		fmt.Fprintf(out, "%-16s %4v %d '%s'\n", op, TokenType(c.Code[offset+1]), index, stringify(c.Constants[index]))
+		return offset + 4
+	case OP_COMPARE_JUMP:
+		fmt.Fprintf(out, "%-16s %4v %d -> %d\n", op, TokenType(c.Code[offset+1]), offset, offset+4+c.readShort(offset+2))
+		return offset + 4
+	case OP_COMPARE_CONSTANT_JUMP:
+		index := c.readShort(offset + 2)
+		fmt.Fprintf(out, "%-16s %4v %d '%s' %d -> %d\n", op, TokenType(c.Code[offset+1]), index, stringify(c.Constants[index]),
+			offset, offset+6+c.readShort(offset+4))
+		return offset + 6
+	case OP_LOOP:
+		fmt.Fprintf(out, "%-16s %4d -> %d\n", op, offset, offset+3-c.readShort(offset+1))
+		return offset + 3
+	case OP_CLOSURE:
+		index := c.readShort(offset + 1)
+		function := c.Constants[index].(*CompiledFunction)
+		fmt.Fprintf(out, "%-16s %4d %s\n", op, index, function.toString())
+		offset += 3
+		for i := 0; i < function.UpvalueCount; i++ {
+			kind := "upvalue"
+			if c.Code[offset] == 1 {
+				kind = "local"
+			}
+			fmt.Fprintf(out, "%04d      |                     %s %d\n", offset, kind, c.Code[offset+1])
+			offset += 2
+		}
+		return offset
+	default:
+		fmt.Fprintf(out, "%s\n", op)
+		return offset + 1
+	}
+}
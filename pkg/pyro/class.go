@@ -0,0 +1,56 @@
+package pyro
+
+// Method is a function stored on a class that can be bound to an instance.
+// The tree-walker's PyroFunction and the VM's Closure both implement it.
+type Method interface {
+	Callable
+	bind(instance *PyroInstance) Callable
+}
+
+type PyroClass struct {
+	Name       string
+	Superclass *PyroClass
+	Methods    map[string]Method
+}
+
+func NewPyroClass(name string, superclass *PyroClass, methods map[string]Method) *PyroClass {
+	return &PyroClass{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+	}
+}
+
+func (pc *PyroClass) findMethod(name string) (Method, bool) {
+	if method, exists := pc.Methods[name]; exists {
+		return method, true
+	}
+
+	if pc.Superclass != nil {
+		return pc.Superclass.findMethod(name)
+	}
+	return nil, false
+}
+
+func (pc *PyroClass) toString() string {
+	return pc.Name
+}
+
+func (pc *PyroClass) Arity() int {
+	if initializer, exists := pc.findMethod("init"); exists {
+		return initializer.Arity()
+	}
+	return 0
+}
+
+func (pc *PyroClass) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	instance := NewPyroInstance(pc)
+
+	if initializer, exists := pc.findMethod("init"); exists {
+		_, err := initializer.bind(instance).Call(interpreter, arguments)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return instance, nil
+}
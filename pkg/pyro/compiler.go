@@ -0,0 +1,846 @@
+package pyro
+
+import "math"
+
+const maxLocals = 256
+const maxUpvalues = 256
+
+type Local struct {
+	Name       string
+	Depth      int
+	IsCaptured bool
+}
+
+type UpvalueRef struct {
+	Index   int
+	IsLocal bool
+}
+
+// Loop tracks an enclosing loop so break and continue can discard the locals
+// declared inside it and jump out once its end and increment are known.
+type Loop struct {
+	ScopeDepth    int
+	BreakJumps    []int
+	ContinueJumps []int
+}
+
+// FunctionCompiler holds the state for the function body being compiled.
+// Each nested function declaration pushes a new one.
+type FunctionCompiler struct {
+	Enclosing  *FunctionCompiler
+	Function   *CompiledFunction
+	Type       FunctionType
+	Locals     []Local
+	Upvalues   []UpvalueRef
+	ScopeDepth int
+	Loops      []*Loop
+}
+
+func NewFunctionCompiler(enclosing *FunctionCompiler, functionType FunctionType, name string) *FunctionCompiler {
+	fc := &FunctionCompiler{
+		Enclosing: enclosing,
+		Function:  NewCompiledFunction(name),
+		Type:      functionType,
+		Locals:    make([]Local, 0, 8),
+		Upvalues:  make([]UpvalueRef, 0),
+	}
+
+	// Slot zero holds the receiver in methods and the callee otherwise.
+	slotZero := ""
+	if functionType == METHOD || functionType == INITIALIZER {
+		slotZero = "this"
+	}
+	fc.Locals = append(fc.Locals, Local{Name: slotZero, Depth: 0})
+	return fc
+}
+
+type ClassCompiler struct {
+	Enclosing     *ClassCompiler
+	HasSuperclass bool
+}
+
+// Compiler translates a resolved syntax tree into bytecode for the VM. It
+// expects the Resolver to have already reported static errors. Globals is
+// the environment whose variables the compiled code refers to.
+type Compiler struct {
+	Current      *FunctionCompiler
+	CurrentClass *ClassCompiler
+	Diagnostics  *Diagnostics
+	Globals      *Environment
+	span         Span
+}
+
+func NewCompiler(diagnostics *Diagnostics, globals *Environment) *Compiler {
+	return &Compiler{
+		Diagnostics: diagnostics,
+		Globals:     globals,
+	}
+}
+
+// compile returns the top-level script as a function taking no arguments.
+// A trailing expression statement becomes the script's return value.
+func (c *Compiler) compile(statements []Stmt) *CompiledFunction {
+	c.Current = NewFunctionCompiler(nil, NONE_FUNCTION, "")
+	for i, statement := range statements {
+		if last, isExpr := statement.(Expression); isExpr && i == len(statements)-1 {
+			c.span = last.Span()
+			c.compileExpr(last.Expression)
+			c.emitOp(OP_RETURN)
+			return c.Current.Function
+		}
+		c.compileStmt(statement)
+	}
+	c.emitReturn()
+	return c.Current.Function
+}
+
+func (c *Compiler) compileStmt(stmt Stmt) {
+	previous := c.span
+	c.span = stmt.Span()
+	stmt.Accept(c)
+	c.span = previous
+}
+
+func (c *Compiler) compileExpr(expr Expr) {
+	expr.Accept(c)
+}
+
+func (c *Compiler) error(span Span, message string) {
+	err := NewError(span.Line, message, "")
+	err.Kind = COMPILE_ERROR
+	err.Span = span
+	err.Column = span.Column
+	c.Diagnostics.report(err)
+}
+
+func (c *Compiler) chunk() *Chunk {
+	return c.Current.Function.Chunk
+}
+
+func (c *Compiler) emit(bytes ...byte) {
+	for _, b := range bytes {
+		c.chunk().write(b, c.span)
+	}
+}
+
+func (c *Compiler) emitOp(op OpCode, operands ...byte) {
+	c.emit(byte(op))
+	c.emit(operands...)
+}
+
+func (c *Compiler) emitShortOp(op OpCode, operand int) {
+	c.emit(byte(op), byte(operand>>8), byte(operand))
+}
+
+func (c *Compiler) emitReturn() {
+	if c.Current.Type == INITIALIZER {
+		c.emitOp(OP_GET_LOCAL, 0)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+	c.emitOp(OP_RETURN)
+}
+
+func (c *Compiler) makeConstant(value interface{}) int {
+	index := c.chunk().addConstant(value)
+	if index > math.MaxUint16 {
+		c.error(c.span, "Too many constants in one chunk.")
+		return 0
+	}
+	return index
+}
+
+func (c *Compiler) emitConstant(value interface{}) {
+	c.emitShortOp(OP_CONSTANT, c.makeConstant(value))
+}
+
+func (c *Compiler) emitJump(op OpCode) int {
+	c.emit(byte(op), 0xff, 0xff)
+	return len(c.chunk().Code) - 2
+}
+
+func (c *Compiler) patchJump(offset int) {
+	jump := len(c.chunk().Code) - offset - 2
+	if jump > math.MaxUint16 {
+		c.error(c.span, "Too much code to jump over.")
+	}
+	c.chunk().Code[offset] = byte(jump >> 8)
+	c.chunk().Code[offset+1] = byte(jump)
+}
+
+func (c *Compiler) emitLoop(loopStart int) {
+	offset := len(c.chunk().Code) - loopStart + 3
+	if offset > math.MaxUint16 {
+		c.error(c.span, "Loop body too large.")
+	}
+	c.emitShortOp(OP_LOOP, offset)
+}
+
+func (c *Compiler) beginScope() {
+	c.Current.ScopeDepth++
+}
+
+func (c *Compiler) endScope() {
+	fc := c.Current
+	fc.ScopeDepth--
+
+	for len(fc.Locals) > 0 && fc.Locals[len(fc.Locals)-1].Depth > fc.ScopeDepth {
+		if fc.Locals[len(fc.Locals)-1].IsCaptured {
+			c.emitOp(OP_CLOSE_UPVALUE)
+		} else {
+			c.emitOp(OP_POP)
+		}
+		fc.Locals = fc.Locals[:len(fc.Locals)-1]
+	}
+}
+
+// discardLocals emits the pops for locals deeper than depth without
+// forgetting them, for jumps that leave scopes the compiler is still inside.
+func (c *Compiler) discardLocals(depth int) {
+	fc := c.Current
+	for i := len(fc.Locals) - 1; i >= 0 && fc.Locals[i].Depth > depth; i-- {
+		if fc.Locals[i].IsCaptured {
+			c.emitOp(OP_CLOSE_UPVALUE)
+		} else {
+			c.emitOp(OP_POP)
+		}
+	}
+}
+
+func (c *Compiler) addLocal(name Token) {
+	if len(c.Current.Locals) >= maxLocals {
+		c.error(name.Span, "Too many local variables in function.")
+		return
+	}
+	c.Current.Locals = append(c.Current.Locals, Local{Name: name.Lexeme, Depth: c.Current.ScopeDepth})
+}
+
+// defineVariable emits the store for a value already on the stack: locals
+// simply stay in their slot, globals are moved into their Global.
+func (c *Compiler) defineVariable(name Token) {
+	if c.Current.ScopeDepth > 0 {
+		c.addLocal(name)
+		return
+	}
+	c.emitShortOp(OP_DEFINE_GLOBAL, c.makeConstant(c.Globals.global(name.Lexeme)))
+}
+
+func resolveLocal(fc *FunctionCompiler, name string) int {
+	for i := len(fc.Locals) - 1; i >= 0; i-- {
+		if fc.Locals[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) addUpvalue(fc *FunctionCompiler, index int, isLocal bool, span Span) int {
+	for i, upvalue := range fc.Upvalues {
+		if upvalue.Index == index && upvalue.IsLocal == isLocal {
+			return i
+		}
+	}
+
+	if len(fc.Upvalues) >= maxUpvalues {
+		c.error(span, "Too many closure variables in function.")
+		return 0
+	}
+	fc.Upvalues = append(fc.Upvalues, UpvalueRef{Index: index, IsLocal: isLocal})
+	fc.Function.UpvalueCount = len(fc.Upvalues)
+	return len(fc.Upvalues) - 1
+}
+
+func (c *Compiler) resolveUpvalue(fc *FunctionCompiler, name string, span Span) int {
+	if fc.Enclosing == nil {
+		return -1
+	}
+
+	if local := resolveLocal(fc.Enclosing, name); local != -1 {
+		fc.Enclosing.Locals[local].IsCaptured = true
+		return c.addUpvalue(fc, local, true, span)
+	}
+
+	if upvalue := c.resolveUpvalue(fc.Enclosing, name, span); upvalue != -1 {
+		return c.addUpvalue(fc, upvalue, false, span)
+	}
+	return -1
+}
+
+func (c *Compiler) namedVariable(name Token, assign Expr) {
+	get, set, _ := c.variable(name)
+	if assign == nil {
+		get()
+		return
+	}
+	c.compileExpr(assign)
+	set()
+}
+
+// variable resolves name and returns functions that emit the instructions
+// reading it, writing the value on top of the stack to it, and moving that
+// value off the stack into it.
+func (c *Compiler) variable(name Token) (get func(), set func(), store func()) {
+	getOp, setOp, storeOp := OP_GET_GLOBAL, OP_SET_GLOBAL, OP_STORE_GLOBAL
+	arg := resolveLocal(c.Current, name.Lexeme)
+	if arg != -1 {
+		getOp, setOp, storeOp = OP_GET_LOCAL, OP_SET_LOCAL, OP_STORE_LOCAL
+	} else if arg = c.resolveUpvalue(c.Current, name.Lexeme, name.Span); arg != -1 {
+		getOp, setOp, storeOp = OP_GET_UPVALUE, OP_SET_UPVALUE, OP_NIL
+	}
+
+	emit := func(op OpCode) func() {
+		return func() {
+			c.withSpan(name.Span, func() {
+				if arg == -1 {
+					c.emitShortOp(op, c.makeConstant(c.Globals.global(name.Lexeme)))
+				} else {
+					c.emitOp(op, byte(arg))
+				}
+			})
+		}
+	}
+	get, set = emit(getOp), emit(setOp)
+	if storeOp == OP_NIL {
+		// Upvalues are rarely assigned in hot code, so they have no store.
+		return get, set, func() {
+			set()
+			c.emitOp(OP_POP)
+		}
+	}
+	return get, set, emit(storeOp)
+}
+
+func (c *Compiler) function(stmt Function, functionType FunctionType) {
+	c.Current = NewFunctionCompiler(c.Current, functionType, stmt.Name.Lexeme)
+	c.beginScope()
+
+	c.Current.Function.Arity = len(stmt.Params)
+	for _, param := range stmt.Params {
+		c.addLocal(param)
+	}
+	for _, statement := range stmt.Body {
+		c.compileStmt(statement)
+	}
+	c.emitReturn()
+
+	compiled := c.Current
+	c.Current = compiled.Enclosing
+
+	c.emitShortOp(OP_CLOSURE, c.makeConstant(compiled.Function))
+	for _, upvalue := range compiled.Upvalues {
+		isLocal := byte(0)
+		if upvalue.IsLocal {
+			isLocal = 1
+		}
+		c.emit(isLocal, byte(upvalue.Index))
+	}
+}
+
+func (c *Compiler) VisitExpressionStmt(stmt Expression) error {
+	c.compileEffect(stmt.Expression)
+	return nil
+}
+
+// compileEffect compiles an expression whose value is discarded. An
+// assignment then moves its value straight into the variable, and a postfix
+// update needs no copy of the old value.
+func (c *Compiler) compileEffect(expr Expr) {
+	switch expr := expr.(type) {
+	case Assign:
+		_, _, store := c.variable(expr.Name)
+		c.compileExpr(expr.Value)
+		store()
+		return
+	case CompoundAssign:
+		c.update(expr, true)
+		return
+	}
+	c.compileExpr(expr)
+	c.emitOp(OP_POP)
+}
+
+// jumpUnless compiles condition and emits a forward jump taken when it is
+// falsey, returning the jump to patch. A comparison jumps on its own
+// outcome rather than pushing a boolean and testing that.
+func (c *Compiler) jumpUnless(condition Expr) int {
+	comparison, isBinary := condition.(Binary)
+	if !isBinary || !isComparison(comparison.Operator.Type) {
+		c.compileExpr(condition)
+		return c.emitJump(OP_POP_JUMP_IF_FALSE)
+	}
+
+	c.compileExpr(comparison.Left)
+	operator := byte(comparison.Operator.Type)
+	index, isNumber := c.numberConstant(comparison.Right)
+	if !isNumber {
+		c.compileExpr(comparison.Right)
+	}
+	c.withSpan(comparison.Operator.Span, func() {
+		if isNumber {
+			c.emitOp(OP_COMPARE_CONSTANT_JUMP, operator, byte(index>>8), byte(index), 0xff, 0xff)
+		} else {
+			c.emitOp(OP_COMPARE_JUMP, operator, 0xff, 0xff)
+		}
+	})
+	return len(c.chunk().Code) - 2
+}
+
+// numberConstant adds expr to the constant pool if it is a number literal,
+// so an operator can take it as an operand instead of a separate push.
+func (c *Compiler) numberConstant(expr Expr) (int, bool) {
+	literal, isLiteral := expr.(Literal)
+	if !isLiteral {
+		return 0, false
+	}
+	switch literal.Value.(type) {
+	case int64, float64:
+		return c.makeConstant(literal.Value), true
+	}
+	return 0, false
+}
+
+func isComparison(operator TokenType) bool {
+	switch operator {
+	case EQEQ, NE, GT, GE, LT, LE:
+		return true
+	}
+	return false
+}
+
+func (c *Compiler) VisitPrintStmt(stmt Print) error {
+	c.compileExpr(stmt.Expression)
+	c.emitOp(OP_PRINT)
+	return nil
+}
+
+func (c *Compiler) VisitVarStmt(stmt Var) error {
+	if stmt.Initalizer != nil {
+		c.compileExpr(*stmt.Initalizer)
+	} else {
+		c.emitOp(OP_NIL)
+	}
+	c.defineVariable(stmt.Name)
+	return nil
+}
+
+func (c *Compiler) VisitBlockStmt(stmt Block) error {
+	c.beginScope()
+	for _, statement := range stmt.Statements {
+		c.compileStmt(statement)
+	}
+	c.endScope()
+	return nil
+}
+
+func (c *Compiler) VisitIfStmt(stmt If) error {
+	thenJump := c.jumpUnless(stmt.Condition)
+	c.compileStmt(stmt.ThenBranch)
+	if stmt.ElseBranch == nil {
+		c.patchJump(thenJump)
+		return nil
+	}
+
+	elseJump := c.emitJump(OP_JUMP)
+	c.patchJump(thenJump)
+	c.compileStmt(*stmt.ElseBranch)
+	c.patchJump(elseJump)
+	return nil
+}
+
+func (c *Compiler) VisitWhileStmt(stmt While) error {
+	fc := c.Current
+	loop := &Loop{ScopeDepth: fc.ScopeDepth}
+	fc.Loops = append(fc.Loops, loop)
+
+	loopStart := len(c.chunk().Code)
+	exitJump := c.jumpUnless(stmt.Condition)
+	c.compileStmt(stmt.Body)
+
+	for _, jump := range loop.ContinueJumps {
+		c.patchJump(jump)
+	}
+	if stmt.Increment != nil {
+		c.compileEffect(*stmt.Increment)
+	}
+	c.emitLoop(loopStart)
+	c.patchJump(exitJump)
+	for _, jump := range loop.BreakJumps {
+		c.patchJump(jump)
+	}
+
+	fc.Loops = fc.Loops[:len(fc.Loops)-1]
+	return nil
+}
+
+func (c *Compiler) VisitBreakStmt(stmt Break) error {
+	loop := c.Current.Loops[len(c.Current.Loops)-1]
+	c.discardLocals(loop.ScopeDepth)
+	loop.BreakJumps = append(loop.BreakJumps, c.emitJump(OP_JUMP))
+	return nil
+}
+
+func (c *Compiler) VisitContinueStmt(stmt Continue) error {
+	loop := c.Current.Loops[len(c.Current.Loops)-1]
+	c.discardLocals(loop.ScopeDepth)
+	loop.ContinueJumps = append(loop.ContinueJumps, c.emitJump(OP_JUMP))
+	return nil
+}
+
+func (c *Compiler) VisitFunctionStmt(stmt Function) error {
+	// A local function is in scope inside its own body so it can recurse.
+	if c.Current.ScopeDepth > 0 {
+		c.addLocal(stmt.Name)
+		c.function(stmt, FUNCTION)
+		return nil
+	}
+
+	c.function(stmt, FUNCTION)
+	c.defineVariable(stmt.Name)
+	return nil
+}
+
+func (c *Compiler) VisitReturnStmt(stmt Return) error {
+	if stmt.Value == nil {
+		c.emitReturn()
+		return nil
+	}
+
+	c.compileExpr(*stmt.Value)
+	c.emitOp(OP_RETURN)
+	return nil
+}
+
+func (c *Compiler) VisitClassStmt(stmt Class) error {
+	nameConstant := c.makeConstant(stmt.Name.Lexeme)
+	c.emitShortOp(OP_CLASS, nameConstant)
+	c.defineVariable(stmt.Name)
+
+	c.CurrentClass = &ClassCompiler{Enclosing: c.CurrentClass}
+
+	if stmt.Superclass != nil {
+		c.beginScope()
+		c.namedVariable(stmt.Superclass.Name, nil)
+		c.addLocal(Token{Type: SUPER, Lexeme: "super", Span: stmt.Superclass.Name.Span})
+
+		c.namedVariable(stmt.Name, nil)
+		previous := c.span
+		c.span = stmt.Superclass.Name.Span
+		c.emitOp(OP_INHERIT)
+		c.span = previous
+		c.CurrentClass.HasSuperclass = true
+	}
+
+	c.namedVariable(stmt.Name, nil)
+	for _, method := range stmt.Methods {
+		functionType := METHOD
+		if method.Name.Lexeme == "init" {
+			functionType = INITIALIZER
+		}
+		c.function(method, functionType)
+		c.emitShortOp(OP_METHOD, c.makeConstant(method.Name.Lexeme))
+	}
+	c.emitOp(OP_POP)
+
+	if c.CurrentClass.HasSuperclass {
+		c.endScope()
+	}
+	c.CurrentClass = c.CurrentClass.Enclosing
+	return nil
+}
+
+func (c *Compiler) VisitLiteralExpr(expr Literal) (interface{}, error) {
+	switch expr.Value {
+	case nil:
+		c.emitOp(OP_NIL)
+	case true:
+		c.emitOp(OP_TRUE)
+	case false:
+		c.emitOp(OP_FALSE)
+	default:
+		c.emitConstant(expr.Value)
+	}
+	return nil, nil
+}
+
+func (c *Compiler) VisitGroupingExpr(expr Grouping) (interface{}, error) {
+	c.compileExpr(expr.Expression)
+	return nil, nil
+}
+
+func (c *Compiler) VisitVariableExpr(expr Variable) (interface{}, error) {
+	c.namedVariable(expr.Name, nil)
+	return nil, nil
+}
+
+func (c *Compiler) VisitAssignExpr(expr Assign) (interface{}, error) {
+	c.namedVariable(expr.Name, expr.Value)
+	return nil, nil
+}
+
+func (c *Compiler) VisitUnaryExpr(expr Unary) (interface{}, error) {
+	c.compileExpr(expr.Right)
+	c.withSpan(expr.Operator.Span, func() {
+		c.emitOp(OP_UNARY, byte(expr.Operator.Type))
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitBinaryExpr(expr Binary) (interface{}, error) {
+	c.compileExpr(expr.Left)
+	c.applyOperator(expr.Operator, expr.Right)
+	return nil, nil
+}
+
+// applyOperator compiles right and the operator applied to it and the value
+// on the stack, taking a number literal as an operand where it can.
+func (c *Compiler) applyOperator(operator Token, right Expr) {
+	if isArithmetic(operator.Type) {
+		if index, isNumber := c.numberConstant(right); isNumber {
+			c.withSpan(operator.Span, func() {
+				c.emitOp(OP_BINARY_CONSTANT, byte(operator.Type), byte(index>>8), byte(index))
+			})
+			return
+		}
+	}
+	c.compileExpr(right)
+	c.binary(operator)
+}
+
+// isArithmetic reports whether operator has a dedicated arithmetic
+// instruction, and so a fast path for a constant right operand.
+func isArithmetic(operator TokenType) bool {
+	switch operator {
+	case PLUS, MINUS, STAR, SLASH, MOD:
+		return true
+	}
+	return false
+}
+
+func (c *Compiler) binary(operator Token) {
+	c.withSpan(operator.Span, func() {
+		if op, isDedicated := binaryOpcodes[operator.Type]; isDedicated {
+			c.emitOp(op)
+			return
+		}
+		c.emitOp(OP_BINARY, byte(operator.Type))
+	})
+}
+
+func (c *Compiler) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, error) {
+	c.update(expr, false)
+	return nil, nil
+}
+
+// update compiles a compound assignment or ++/--. It leaves the target's
+// object and index on the stack under its current value, so they are
+// evaluated once and reused by the store. A postfix update buries a copy of
+// the old value beneath them as the result. If discard is set the result is
+// not wanted, so postfix is compiled like prefix and nothing is left.
+func (c *Compiler) update(expr CompoundAssign, discard bool) {
+	var store func()
+	operands := 0
+	switch target := expr.Target.(type) {
+	case Variable:
+		load, set, move := c.variable(target.Name)
+		load()
+		store = set
+		if discard {
+			store = move
+		}
+	case Get:
+		c.compileExpr(target.Object)
+		c.emitOp(OP_DUP, 1)
+		name := c.makeConstant(target.Name.Lexeme)
+		c.withSpan(target.Name.Span, func() {
+			c.emitShortOp(OP_GET_PROPERTY, name)
+		})
+		store = func() {
+			c.withSpan(target.Name.Span, func() {
+				c.emitShortOp(OP_SET_PROPERTY, name)
+			})
+		}
+		operands = 1
+	case Subscript:
+		c.compileExpr(target.Object)
+		c.compileExpr(target.Index)
+		c.emitOp(OP_DUP, 2)
+		c.withSpan(target.Bracket.Span, func() {
+			c.emitOp(OP_GET_INDEX)
+		})
+		store = func() {
+			c.withSpan(target.Bracket.Span, func() {
+				c.emitOp(OP_SET_INDEX)
+			})
+		}
+		operands = 2
+	}
+
+	postfix := expr.Postfix && !discard
+	if postfix {
+		c.emitOp(OP_DUP, 1)
+		if operands > 0 {
+			c.emitOp(OP_BURY, byte(operands+1))
+		}
+	}
+	c.applyOperator(expr.binaryOperator(), expr.Value)
+	store()
+	if postfix || discard && operands > 0 {
+		c.emitOp(OP_POP)
+	}
+}
+
+func (c *Compiler) VisitLogicalExpr(expr Logical) (interface{}, error) {
+	c.compileExpr(expr.Left)
+
+	if expr.Operator.Type == AND {
+		endJump := c.emitJump(OP_JUMP_IF_FALSE)
+		c.emitOp(OP_POP)
+		c.compileExpr(expr.Right)
+		c.patchJump(endJump)
+		return nil, nil
+	}
+
+	elseJump := c.emitJump(OP_JUMP_IF_FALSE)
+	endJump := c.emitJump(OP_JUMP)
+	c.patchJump(elseJump)
+	c.emitOp(OP_POP)
+	c.compileExpr(expr.Right)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitConditionalExpr(expr Conditional) (interface{}, error) {
+	c.compileExpr(expr.Condition)
+	elseJump := c.emitJump(OP_POP_JUMP_IF_FALSE)
+	c.compileExpr(expr.ThenBranch)
+	endJump := c.emitJump(OP_JUMP)
+	c.patchJump(elseJump)
+	c.compileExpr(expr.ElseBranch)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitCoalesceExpr(expr Coalesce) (interface{}, error) {
+	c.compileExpr(expr.Left)
+	endJump := c.emitJump(OP_JUMP_IF_NOT_NIL)
+	c.emitOp(OP_POP)
+	c.compileExpr(expr.Right)
+	c.patchJump(endJump)
+	return nil, nil
+}
+
+func (c *Compiler) VisitCallExpr(expr Call) (interface{}, error) {
+	c.compileExpr(expr.Callee)
+	for _, argument := range expr.Arguments {
+		c.compileExpr(argument)
+	}
+	c.withSpan(expr.Paren.Span, func() {
+		c.emitOp(OP_CALL, byte(len(expr.Arguments)))
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitGetExpr(expr Get) (interface{}, error) {
+	c.compileExpr(expr.Object)
+	c.withSpan(expr.Name.Span, func() {
+		c.emitShortOp(OP_GET_PROPERTY, c.makeConstant(expr.Name.Lexeme))
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitSetExpr(expr Set) (interface{}, error) {
+	c.compileExpr(expr.Object)
+	c.compileExpr(expr.Value)
+	c.withSpan(expr.Name.Span, func() {
+		c.emitShortOp(OP_SET_PROPERTY, c.makeConstant(expr.Name.Lexeme))
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitListExpr(expr List) (interface{}, error) {
+	if len(expr.Elements) > math.MaxUint16 {
+		c.error(expr.Location, "Too many elements in list literal.")
+		return nil, nil
+	}
+	for _, element := range expr.Elements {
+		c.compileExpr(element)
+	}
+	c.withSpan(expr.Location, func() {
+		c.emitShortOp(OP_LIST, len(expr.Elements))
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitInterpolationExpr(expr Interpolation) (interface{}, error) {
+	if len(expr.Parts) > math.MaxUint16 {
+		c.error(expr.Location, "Too many parts in interpolated string.")
+		return nil, nil
+	}
+	for _, part := range expr.Parts {
+		c.compileExpr(part)
+	}
+	c.emitShortOp(OP_INTERPOLATE, len(expr.Parts))
+	return nil, nil
+}
+
+func (c *Compiler) VisitLambdaExpr(expr Lambda) (interface{}, error) {
+	c.function(expr.Declaration, FUNCTION)
+	return nil, nil
+}
+
+func (c *Compiler) VisitMapExpr(expr Map) (interface{}, error) {
+	if len(expr.Keys) > math.MaxUint16 {
+		c.error(expr.Location, "Too many entries in map literal.")
+		return nil, nil
+	}
+	for i := range expr.Keys {
+		c.compileExpr(expr.Keys[i])
+		c.compileExpr(expr.Values[i])
+	}
+	c.withSpan(expr.Brace.Span, func() {
+		c.emitShortOp(OP_MAP, len(expr.Keys))
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitSubscriptExpr(expr Subscript) (interface{}, error) {
+	c.compileExpr(expr.Object)
+	c.compileExpr(expr.Index)
+	c.withSpan(expr.Bracket.Span, func() {
+		c.emitOp(OP_GET_INDEX)
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitSubscriptSetExpr(expr SubscriptSet) (interface{}, error) {
+	c.compileExpr(expr.Object)
+	c.compileExpr(expr.Index)
+	c.compileExpr(expr.Value)
+	c.withSpan(expr.Bracket.Span, func() {
+		c.emitOp(OP_SET_INDEX)
+	})
+	return nil, nil
+}
+
+func (c *Compiler) VisitThisExpr(expr This) (interface{}, error) {
+	c.namedVariable(expr.Keyword, nil)
+	return nil, nil
+}
+
+func (c *Compiler) VisitSuperExpr(expr Super) (interface{}, error) {
+	c.namedVariable(Token{Type: THIS, Lexeme: "this", Span: expr.Keyword.Span}, nil)
+	c.namedVariable(expr.Keyword, nil)
+	c.withSpan(expr.Method.Span, func() {
+		c.emitShortOp(OP_GET_SUPER, c.makeConstant(expr.Method.Lexeme))
+	})
+	return nil, nil
+}
+
+// withSpan attributes the bytes emitted by emit to span, so runtime errors
+// point at the operator or name rather than the whole statement.
+func (c *Compiler) withSpan(span Span, emit func()) {
+	previous := c.span
+	c.span = span
+	emit()
+	c.span = previous
+}
@@ -0,0 +1,336 @@
+package pyro
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// PyroDecimal is an exact decimal number, written 12.50d in a script. Its
+// value is unscaled / 10**scale. The scale is kept through arithmetic, so
+// 12.50d prints as 12.50 and 0.1d + 0.2d is exactly 0.3. Decimals are
+// immutable.
+type PyroDecimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// decimalContext is how / and ** round a decimal result that does not
+// terminate: to digits significant digits, by mode. decimal_context() sets
+// it for the rest of a program.
+type decimalContext struct {
+	digits int
+	mode   roundingMode
+}
+
+// defaultDecimalContext has the precision of an IEEE decimal128.
+var defaultDecimalContext = decimalContext{digits: 34, mode: roundHalfEven}
+
+func newDecimalContext(digits int64, mode string) (decimalContext, error) {
+	if digits < 1 || digits > maxIntegerBits {
+		return decimalContext{}, errors.New("Decimal digits must be between 1 and " + strconv.Itoa(maxIntegerBits) + ".")
+	}
+	rounding, err := parseRoundingMode(mode)
+	if err != nil {
+		return decimalContext{}, err
+	}
+	return decimalContext{digits: int(digits), mode: rounding}, nil
+}
+
+var errMixedDecimal = errors.New("Can't mix decimal and float operands; convert one with decimal() or float()")
+
+func newDecimal(unscaled *big.Int, scale int) *PyroDecimal {
+	return &PyroDecimal{unscaled: unscaled, scale: scale}
+}
+
+// ParseDecimal reads a decimal such as "-12.50", keeping its digits after
+// the point.
+func ParseDecimal(text string) (*PyroDecimal, error) {
+	digits := strings.ReplaceAll(text, "_", "")
+	scale := 0
+	if point := strings.IndexByte(digits, '.'); point >= 0 {
+		scale = len(digits) - point - 1
+		digits = digits[:point] + digits[point+1:]
+	}
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, errors.New("invalid decimal " + strconv.Quote(text))
+	}
+	return newDecimal(unscaled, scale), nil
+}
+
+func (d *PyroDecimal) String() string {
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Rat returns the exact value of d.
+func (d *PyroDecimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func isDecimal(value interface{}) bool {
+	_, isDecimal := value.(*PyroDecimal)
+	return isDecimal
+}
+
+// toDecimal converts an integer or a decimal to a decimal.
+func toDecimal(value interface{}) *PyroDecimal {
+	if d, isDecimal := value.(*PyroDecimal); isDecimal {
+		return d
+	}
+	return newDecimal(toBig(value), 0)
+}
+
+// floatToDecimal converts a float by its shortest printed form, so
+// decimal(0.1) is 0.1 rather than the binary fraction the float holds.
+func floatToDecimal(f float64) (*PyroDecimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.New("Can't convert " + formatFloat(f) + " to a decimal.")
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// rescaled returns the unscaled digits of d at a scale no smaller than its
+// own.
+func (d *PyroDecimal) rescaled(scale int) *big.Int {
+	if scale == d.scale {
+		return d.unscaled
+	}
+	return new(big.Int).Mul(d.unscaled, pow10(scale-d.scale))
+}
+
+// integer returns the whole part of d, truncated toward zero.
+func (d *PyroDecimal) integer() interface{} {
+	if d.scale == 0 {
+		return normalize(d.unscaled)
+	}
+	return normalize(new(big.Int).Quo(d.unscaled, pow10(d.scale)))
+}
+
+// isWhole reports whether d has no fractional part.
+func (d *PyroDecimal) isWhole() bool {
+	return d.scale == 0 || new(big.Int).Rem(d.unscaled, pow10(d.scale)).Sign() == 0
+}
+
+// decimalArithmetic is arithmetic when either operand is a decimal and
+// neither is a float. Every operator gives a decimal; only / and ** round,
+// as context says.
+func decimalArithmetic(operator Token, left interface{}, right interface{}, context decimalContext) (interface{}, error) {
+	l, r := toDecimal(left), toDecimal(right)
+	scale := l.scale
+	if r.scale > scale {
+		scale = r.scale
+	}
+
+	switch operator.Type {
+	case PLUS:
+		return newDecimal(new(big.Int).Add(l.rescaled(scale), r.rescaled(scale)), scale), nil
+	case MINUS:
+		return newDecimal(new(big.Int).Sub(l.rescaled(scale), r.rescaled(scale)), scale), nil
+	case STAR:
+		return newDecimal(new(big.Int).Mul(l.unscaled, r.unscaled), l.scale+r.scale), nil
+	case STARSTAR:
+		return decimalPower(operator, l, right, context)
+	}
+
+	if r.unscaled.Sign() == 0 {
+		return nil, NewRunTimeError(operator, "Division by zero")
+	}
+	switch operator.Type {
+	case SLASH:
+		return quotient(l, r, context), nil
+	case SLASHSLASH:
+		result, _ := bigArithmetic(SLASHSLASH, l.rescaled(scale), r.rescaled(scale))
+		return newDecimal(toBig(result), 0), nil
+	}
+	result, _ := bigArithmetic(MOD, l.rescaled(scale), r.rescaled(scale))
+	return newDecimal(toBig(result), scale), nil
+}
+
+// decimalPower raises a decimal to a whole exponent. A negative exponent
+// divides, and so may round.
+func decimalPower(operator Token, base *PyroDecimal, exponent interface{}, context decimalContext) (interface{}, error) {
+	if d, isDecimal := exponent.(*PyroDecimal); isDecimal {
+		if !d.isWhole() {
+			return nil, NewRunTimeError(operator, "Decimal exponent must be a whole number")
+		}
+		exponent = d.integer()
+	}
+
+	n, isSmall := exponent.(int64)
+	if base.scale == 0 && base.unscaled.CmpAbs(big.NewInt(1)) <= 0 {
+		if !isSmall {
+			// Powers of 0, 1 and -1 depend only on the exponent's sign and parity.
+			n = int64(2 + toBig(exponent).Bit(0))
+			if sign(exponent) < 0 {
+				n = -n
+			}
+		}
+	} else {
+		bits := int64(base.unscaled.BitLen()) + 4*int64(base.scale)
+		if !isSmall || n > maxIntegerBits || n < -maxIntegerBits || bits*n > maxIntegerBits || bits*n < -maxIntegerBits {
+			return nil, NewRunTimeError(operator, "Decimal result is too large")
+		}
+	}
+
+	magnitude := n
+	if n < 0 {
+		if base.unscaled.Sign() == 0 {
+			return nil, NewRunTimeError(operator, "Division by zero")
+		}
+		magnitude = -n
+	}
+	power := newDecimal(new(big.Int).Exp(base.unscaled, big.NewInt(magnitude), nil), base.scale*int(magnitude))
+	if n < 0 {
+		return quotient(newDecimal(big.NewInt(1), 0), power, context), nil
+	}
+	return power, nil
+}
+
+// quotient divides two decimals. A quotient that terminates within
+// context.digits significant digits is exact; otherwise it is rounded by
+// context.mode. Trailing zeros are dropped down to the difference of the
+// operands' scales, so 10.00d / 4 is 2.50.
+func quotient(l *PyroDecimal, r *PyroDecimal, context decimalContext) *PyroDecimal {
+	exact := new(big.Rat).Quo(l.Rat(), r.Rat())
+	if exact.Sign() == 0 {
+		return newDecimal(new(big.Int), 0)
+	}
+
+	// The leading digit of the quotient is at 10**exponent.
+	num := new(big.Int).Abs(exact.Num())
+	exponent := len(num.String()) - len(exact.Denom().String())
+	if new(big.Rat).Abs(exact).Cmp(new(big.Rat).SetFrac(pow10Signed(exponent))) < 0 {
+		exponent--
+	}
+	result := roundRat(exact, context.digits-1-exponent, context.mode)
+
+	ideal := l.scale - r.scale
+	if ideal < 0 {
+		ideal = 0
+	}
+	ten, digit := big.NewInt(10), new(big.Int)
+	for result.scale > ideal {
+		quo, rem := new(big.Int).QuoRem(result.unscaled, ten, digit)
+		if rem.Sign() != 0 {
+			break
+		}
+		result = newDecimal(quo, result.scale-1)
+	}
+	return result
+}
+
+// pow10Signed returns 10**n as a fraction, for n of either sign.
+func pow10Signed(n int) (*big.Int, *big.Int) {
+	if n < 0 {
+		return big.NewInt(1), pow10(-n)
+	}
+	return pow10(n), big.NewInt(1)
+}
+
+type roundingMode int
+
+const (
+	roundHalfEven roundingMode = iota
+	roundHalfUp
+	roundHalfDown
+	roundUp
+	roundDown
+	roundCeiling
+	roundFloor
+)
+
+// roundingModes are the names round() accepts, indexed by roundingMode.
+var roundingModes = []string{"half-even", "half-up", "half-down", "up", "down", "ceiling", "floor"}
+
+func parseRoundingMode(name string) (roundingMode, error) {
+	for i, mode := range roundingModes {
+		if mode == name {
+			return roundingMode(i), nil
+		}
+	}
+	return 0, errors.New("Unknown rounding mode '" + name + "'; expected one of " + strings.Join(roundingModes, ", ") + ".")
+}
+
+// roundRat rounds value to scale digits after the point. A negative scale
+// rounds to tens, hundreds and so on, and gives a decimal with scale 0.
+//
+// half-even, half-up and half-down round to the nearest value and differ
+// only on ties; up and down round away from and toward zero; ceiling and
+// floor round toward positive and negative infinity.
+func roundRat(value *big.Rat, scale int, mode roundingMode) *PyroDecimal {
+	num, denom := new(big.Int).Set(value.Num()), new(big.Int).Set(value.Denom())
+	if scale >= 0 {
+		num.Mul(num, pow10(scale))
+	} else {
+		denom.Mul(denom, pow10(-scale))
+	}
+
+	quo, rem := new(big.Int).QuoRem(num, denom, new(big.Int))
+	if rem.Sign() != 0 {
+		half := new(big.Int).Abs(rem)
+		half.Lsh(half, 1)
+		tie := half.Cmp(denom)
+		away := false
+		switch mode {
+		case roundHalfEven:
+			away = tie > 0 || tie == 0 && quo.Bit(0) == 1
+		case roundHalfUp:
+			away = tie >= 0
+		case roundHalfDown:
+			away = tie > 0
+		case roundUp:
+			away = true
+		case roundCeiling:
+			away = rem.Sign() > 0
+		case roundFloor:
+			away = rem.Sign() < 0
+		}
+		if away {
+			quo.Add(quo, big.NewInt(int64(rem.Sign())))
+		}
+	}
+
+	if scale < 0 {
+		return newDecimal(quo.Mul(quo, pow10(-scale)), 0)
+	}
+	return newDecimal(quo, scale)
+}
+
+// roundNumber implements round(): value rounded to places digits after the
+// point, keeping its type. A decimal gets exactly places digits, so
+// round(2.5d, 2) is 2.50.
+func roundNumber(value interface{}, places int, mode roundingMode) (interface{}, error) {
+	switch v := value.(type) {
+	case *PyroDecimal:
+		return roundRat(v.Rat(), places, mode), nil
+	case int64, *big.Int:
+		if places >= 0 {
+			return v, nil
+		}
+		return roundRat(new(big.Rat).SetInt(toBig(v)), places, mode).integer(), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return v, nil
+		}
+		f, _ := roundRat(new(big.Rat).SetFloat64(v), places, mode).Rat().Float64()
+		return f, nil
+	}
+	return nil, errors.New("Can't round " + typeName(value) + ".")
+}
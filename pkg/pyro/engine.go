@@ -0,0 +1,469 @@
+package pyro
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Value is any Pyro runtime value: nil, bool, int64, *big.Int (for integers
+// outside the int64 range), float64, *PyroDecimal, string, *PyroList,
+// *PyroMap, or one of the callable and object types defined by this package. Values passed in
+// from Go may also use Go's other integer and float types, []interface{} and
+// map[string]interface{}; they are converted to the types above.
+type Value = interface{}
+
+// GoFunc is a Go function exposed to scripts through Engine.Register. It
+// accepts any number of arguments; a returned error becomes a runtime error
+// at the call site.
+type GoFunc func(args ...Value) (Value, error)
+
+// ErrCompile is returned by Eval when the source has syntax or resolution
+// errors. The individual errors are available from Engine.Diagnostics.
+var ErrCompile = errors.New("pyro: source has compile errors")
+
+// CallError is returned by Call and CallValue. A runtime error raised by the
+// called function is also recorded in the engine's Diagnostics and is
+// available as Diagnostic; Diagnostic is nil when the call could not start.
+type CallError struct {
+	Message    string
+	Diagnostic *Error
+}
+
+func (ce CallError) Error() string {
+	return "pyro: " + ce.Message
+}
+
+// Engine is an embeddable Pyro interpreter. Globals defined by one call to
+// Eval stay visible to later calls on the same Engine.
+type Engine struct {
+	interpreter *Interpreter
+	vm          *VM
+	diagnostics *Diagnostics
+	prelude     map[string]Value
+}
+
+// NewEngine returns an Engine that prints diagnostics to stderr.
+func NewEngine() *Engine {
+	return NewEngineWithDiagnostics(NewStderrDiagnostics())
+}
+
+// NewEngineWithDiagnostics returns an Engine that records its errors in
+// diagnostics. Engines sharing no Diagnostics can run concurrently.
+func NewEngineWithDiagnostics(diagnostics *Diagnostics) *Engine {
+	interpreter := NewInterpreter(diagnostics)
+
+	prelude := make(map[string]Value)
+	for name := range interpreter.Globals.Values {
+		prelude[name], _ = interpreter.Globals.lookup(name)
+	}
+
+	return &Engine{
+		interpreter: interpreter,
+		diagnostics: diagnostics,
+		prelude:     prelude,
+	}
+}
+
+// Diagnostics returns the errors recorded by the most recent Eval or Call.
+func (e *Engine) Diagnostics() *Diagnostics {
+	return e.diagnostics
+}
+
+// UseVM selects whether later calls to Eval compile to bytecode and run on
+// the stack VM instead of walking the syntax tree. Both backends share the
+// same globals.
+func (e *Engine) UseVM(enabled bool) {
+	if !enabled {
+		e.vm = nil
+		return
+	}
+	if e.vm == nil {
+		e.vm = NewVM(e.interpreter)
+	}
+}
+
+// Eval runs source and returns the value of its final statement when that
+// statement is a bare expression, or nil otherwise. Like the REPL, it
+// supplies the ';' that may be left off the final statement, so
+// Eval("grow(1)") calls grow; see TerminateStatement.
+func (e *Engine) Eval(source string) (Value, error) {
+	return e.EvalNamed("<eval>", TerminateStatement(source))
+}
+
+// EvalNamed is Eval with the file name used when rendering diagnostics. It
+// runs source exactly as a script file, so every statement, the last one
+// included, needs its ';'.
+func (e *Engine) EvalNamed(name string, source string) (Value, error) {
+	e.diagnostics.Reset()
+
+	statements, err := e.parse(name, source)
+	if err != nil {
+		return nil, err
+	}
+	return e.execute(statements)
+}
+
+// Compile parses and checks source and returns it in the .pyroc format,
+// ready for EvalCompiled. Nothing is executed.
+func (e *Engine) Compile(name string, source string) ([]byte, error) {
+	e.diagnostics.Reset()
+
+	statements, err := e.parse(name, source)
+	if err != nil {
+		return nil, err
+	}
+
+	// Static errors are reported now; the scope distances are thrown away
+	// and worked out again when the program is loaded.
+	resolver := NewResolver(e.diagnostics, make(map[ExprID]int))
+	err = resolver.resolve(statements)
+	if err != nil {
+		return nil, err
+	}
+	if e.diagnostics.HasErrors() {
+		return nil, ErrCompile
+	}
+	return encodeProgram(name, statements), nil
+}
+
+// EvalCompiled runs a program produced by Compile without scanning or
+// parsing it. A file that is truncated, corrupt or written by a different
+// format version is rejected with a FormatError.
+func (e *Engine) EvalCompiled(data []byte) (Value, error) {
+	e.diagnostics.Reset()
+
+	statements, err := decodeProgram(data)
+	if err != nil {
+		return nil, err
+	}
+	return e.execute(statements)
+}
+
+func (e *Engine) parse(name string, source string) ([]Stmt, error) {
+	scanner := NewScanner(NewSourceFile(name, source), e.diagnostics)
+	parser := NewParser(scanner, e.diagnostics)
+	statements, err := parser.parse()
+	if err != nil {
+		return nil, err
+	}
+	if e.diagnostics.HasErrors() {
+		return nil, ErrCompile
+	}
+	return statements, nil
+}
+
+// execute resolves statements and runs them on the selected backend. Each
+// program gets its own map of scope distances, which the functions it
+// declares keep alive, so repeated calls don't accumulate them.
+func (e *Engine) execute(statements []Stmt) (Value, error) {
+	locals := make(map[ExprID]int)
+	resolver := NewResolver(e.diagnostics, locals)
+	err := resolver.resolve(statements)
+	if err != nil {
+		return nil, err
+	}
+	if e.diagnostics.HasErrors() {
+		return nil, ErrCompile
+	}
+
+	if len(statements) == 0 {
+		return nil, nil
+	}
+
+	e.interpreter.Locals = locals
+	if e.vm != nil {
+		return e.runVM(statements)
+	}
+
+	last, isExpression := statements[len(statements)-1].(Expression)
+	if !isExpression {
+		return nil, e.interpreter.interpret(statements)
+	}
+
+	err = e.interpreter.interpret(statements[:len(statements)-1])
+	if err != nil {
+		return nil, err
+	}
+	return e.interpreter.interpretExpression(last.Expression)
+}
+
+func (e *Engine) runVM(statements []Stmt) (Value, error) {
+	script := NewCompiler(e.diagnostics, e.interpreter.Globals).compile(statements)
+	if e.diagnostics.HasErrors() {
+		return nil, ErrCompile
+	}
+
+	value, err := e.vm.interpret(script)
+	if err != nil {
+		e.interpreter.runtimeError(err)
+		return nil, err
+	}
+	return value, nil
+}
+
+// SetStdin makes the input() built-in read from r instead of os.Stdin.
+func (e *Engine) SetStdin(r io.Reader) {
+	if reader, isBuffered := r.(*bufio.Reader); isBuffered {
+		e.interpreter.Stdin = reader
+		return
+	}
+	e.interpreter.Stdin = bufio.NewReader(r)
+}
+
+// SetStdout makes print statements write to w instead of os.Stdout.
+func (e *Engine) SetStdout(w io.Writer) {
+	e.interpreter.Stdout = w
+}
+
+// SetDecimalContext makes decimal division, and ** with a negative
+// exponent, round a result that does not terminate to digits significant
+// digits using mode, one of the rounding modes round() accepts. Scripts can
+// do the same with decimal_context(digits, mode). The default is 34 digits,
+// rounded half to even.
+func (e *Engine) SetDecimalContext(digits int, mode string) error {
+	context, err := newDecimalContext(int64(digits), mode)
+	if err != nil {
+		return err
+	}
+	e.interpreter.decimals = context
+	return nil
+}
+
+// Globals returns the names of every global defined by scripts or Define,
+// sorted, leaving out the built-in prelude.
+func (e *Engine) Globals() []string {
+	names := make([]string, 0)
+	for name := range e.interpreter.Globals.Values {
+		value, defined := e.interpreter.Globals.lookup(name)
+		if builtin, isBuiltin := e.prelude[name]; !defined || isBuiltin && builtin == value {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Lookup returns the value of the global name.
+func (e *Engine) Lookup(name string) (Value, bool) {
+	return e.interpreter.Globals.lookup(name)
+}
+
+// Define binds name to v in the global scope. It fails, leaving name
+// unbound, if v is a Go value scripts cannot use; see fromGo.
+func (e *Engine) Define(name string, v Value) error {
+	value, err := fromGo(v)
+	if err != nil {
+		return errors.New("pyro: can't define '" + name + "': " + err.Error())
+	}
+	e.interpreter.Globals.define(name, value)
+	return nil
+}
+
+// fromGo converts a Go value for use by a script. Go's other integer and
+// float types become int64, *big.Int or float64, a []interface{} becomes a
+// *PyroList and a map[string]interface{} a *PyroMap, converting their
+// elements in turn. Pyro values pass through unchanged. Anything else, such
+// as a []int or a struct, is an error rather than a value scripts could
+// neither print nor compare.
+func fromGo(v Value) (Value, error) {
+	switch n := v.(type) {
+	case nil, bool, string, int64, *big.Int, float64, *PyroDecimal,
+		*PyroList, *PyroMap, *PyroInstance, Callable:
+		return v, nil
+	case int:
+		return int64(n), nil
+	case int8:
+		return int64(n), nil
+	case int16:
+		return int64(n), nil
+	case int32:
+		return int64(n), nil
+	case uint8:
+		return int64(n), nil
+	case uint16:
+		return int64(n), nil
+	case uint32:
+		return int64(n), nil
+	case uint:
+		return normalize(new(big.Int).SetUint64(uint64(n))), nil
+	case uint64:
+		return normalize(new(big.Int).SetUint64(n)), nil
+	case float32:
+		return float64(n), nil
+	case []interface{}:
+		elements := make([]interface{}, len(n))
+		for i, element := range n {
+			value, err := fromGo(element)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = value
+		}
+		return NewPyroList(elements), nil
+	case map[string]interface{}:
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		// Go maps are unordered; sorting the keys keeps the map's order, and
+		// so its printed form, the same from run to run.
+		sort.Strings(keys)
+		pm := NewPyroMap()
+		for _, key := range keys {
+			value, err := fromGo(n[key])
+			if err != nil {
+				return nil, err
+			}
+			pm.Set(key, value)
+		}
+		return pm, nil
+	}
+	return nil, fmt.Errorf("unsupported Go type %T", v)
+}
+
+// Register exposes fn to scripts as a global function called name.
+func (e *Engine) Register(name string, fn GoFunc) {
+	native := NewNativeFunction(name, -1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+		value, err := fn(arguments...)
+		if err != nil {
+			if _, isExit := err.(ExitError); isExit {
+				return nil, err
+			}
+			return nil, NativeError{Message: err.Error()}
+		}
+		result, err := fromGo(value)
+		if err != nil {
+			return nil, NativeError{Message: name + " returned an " + err.Error() + "."}
+		}
+		return result, nil
+	})
+	e.interpreter.Globals.define(name, native)
+}
+
+// Call invokes the global function or class fnName with args.
+func (e *Engine) Call(fnName string, args ...Value) (Value, error) {
+	value, exists := e.interpreter.Globals.lookup(fnName)
+	if !exists {
+		return nil, CallError{Message: "undefined function '" + fnName + "'"}
+	}
+
+	function, isCallable := value.(Callable)
+	if !isCallable {
+		return nil, CallError{Message: "'" + fnName + "' is not callable"}
+	}
+	return e.call(fnName, function, args)
+}
+
+// CallValue invokes fn, a function value received from a script, such as a
+// callback passed to a function exposed with Register.
+func (e *Engine) CallValue(fn Value, args ...Value) (Value, error) {
+	function, isCallable := fn.(Callable)
+	if !isCallable {
+		return nil, CallError{Message: typeName(fn) + " is not callable"}
+	}
+	return e.call(stringify(fn), function, args)
+}
+
+func (e *Engine) call(name string, function Callable, args []Value) (Value, error) {
+	e.diagnostics.Reset()
+	if function.Arity() >= 0 && len(args) != function.Arity() {
+		return nil, CallError{Message: "'" + name + "' expects " + strconv.Itoa(function.Arity()) + " arguments but got " + strconv.Itoa(len(args))}
+	}
+
+	arguments := make([]interface{}, len(args))
+	for i, arg := range args {
+		argument, err := fromGo(arg)
+		if err != nil {
+			return nil, CallError{Message: "argument " + strconv.Itoa(i+1) + " to '" + name + "': " + err.Error()}
+		}
+		arguments[i] = argument
+	}
+	result, err := function.Call(e.interpreter, arguments)
+	switch err := err.(type) {
+	case nil:
+		return result, nil
+	case ExitError:
+		return nil, err
+	case RunTimeError:
+		e.interpreter.runtimeError(err)
+		return nil, CallError{Message: err.Err.Message, Diagnostic: &err.Err}
+	}
+
+	// A native called directly fails without a location in any script.
+	diagnostic := Error{Kind: RUNTIME_ERROR, Message: err.Error()}
+	e.diagnostics.report(diagnostic)
+	return nil, CallError{Message: diagnostic.Message, Diagnostic: &diagnostic}
+}
+
+// Stringify formats v the way the print statement does.
+func Stringify(v Value) string {
+	return stringify(v)
+}
+
+// IsIncomplete reports whether source stops partway through a construct, such
+// as inside a string literal, an unclosed block or a dangling operator, so an
+// interactive caller should read more lines before evaluating it. A missing
+// ';' at the very end does not count; see TerminateStatement.
+func IsIncomplete(source string) bool {
+	diagnostics, scanner := parseCheck(TerminateStatement(source))
+	if scanner.Unterminated {
+		return true
+	}
+	if !diagnostics.HasErrors() {
+		return false
+	}
+
+	// The parser ran out of input if it first tripped over a supplied ';' or
+	// the end of the text.
+	first := diagnostics.Errors[0]
+	return first.Token != nil && first.Token.Span.Start >= len(source)
+}
+
+// TerminateStatement appends the ';' that an interactive user may leave off
+// the last statement of source, unless source already parses without it.
+func TerminateStatement(source string) string {
+	if strings.HasSuffix(strings.TrimSpace(source), ";") {
+		return source
+	}
+	if diagnostics, _ := parseCheck(source); !diagnostics.HasErrors() {
+		return source
+	}
+	return source + ";"
+}
+
+// parseCheck parses source only to collect its scan and parse errors.
+func parseCheck(source string) (*Diagnostics, *Scanner) {
+	diagnostics := NewDiagnostics(nil)
+	scanner := NewScanner(NewSourceFile("<input>", source), diagnostics)
+	NewParser(scanner, diagnostics).parse()
+	return diagnostics, scanner
+}
+
+// FormatAST parses source and returns its syntax tree as S-expressions, one
+// top-level statement per line.
+func FormatAST(source string) (string, error) {
+	diagnostics := NewDiagnostics(nil)
+	scanner := NewScanner(NewSourceFile("<ast>", source), diagnostics)
+	parser := NewParser(scanner, diagnostics)
+	statements, err := parser.parse()
+	if err != nil {
+		return "", err
+	}
+	if diagnostics.HasErrors() {
+		return "", errors.New(diagnostics.Errors[0].Error())
+	}
+
+	lines := make([]string, 0, len(statements))
+	printer := AstPrinter{}
+	for _, statement := range statements {
+		lines = append(lines, printer.PrintStmt(statement))
+	}
+	return strings.Join(lines, "\n"), nil
+}
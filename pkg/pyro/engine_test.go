@@ -0,0 +1,204 @@
+package pyro
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newTestEngine returns an engine writing to out whose diagnostics are not
+// printed.
+func newTestEngine(out io.Writer) *Engine {
+	engine := NewEngineWithDiagnostics(NewDiagnostics(nil))
+	engine.SetStdout(out)
+	return engine
+}
+
+func TestDefineConvertsGoValues(t *testing.T) {
+	for _, useVM := range []bool{false, true} {
+		var out bytes.Buffer
+		engine := newTestEngine(&out)
+		engine.UseVM(useVM)
+		value := []interface{}{1, uint8(2), "three", map[string]interface{}{"b": nil, "a": []interface{}{true}}}
+		if err := engine.Define("xs", value); err != nil {
+			t.Fatalf("Define: %v", err)
+		}
+		if _, err := engine.Eval(`print xs; print xs == xs; print len(xs[3]);`); err != nil {
+			t.Fatalf("vm=%v: %v", useVM, err)
+		}
+		if want := "[1, 2, \"three\", {\"a\": [true], \"b\": nil}]\ntrue\n2\n"; out.String() != want {
+			t.Errorf("vm=%v: got %q, want %q", useVM, out.String(), want)
+		}
+	}
+}
+
+func TestUnsupportedGoValuesAreRefused(t *testing.T) {
+	for _, useVM := range []bool{false, true} {
+		engine := newTestEngine(io.Discard)
+		engine.UseVM(useVM)
+		for _, value := range []Value{[]int{1, 2}, map[string]int{"a": 1}, struct{}{}, []interface{}{[]int{1}}} {
+			if err := engine.Define("xs", value); err == nil {
+				t.Errorf("Define(%T) succeeded", value)
+			}
+		}
+		if _, exists := engine.Lookup("xs"); exists {
+			t.Errorf("a refused value was defined")
+		}
+
+		engine.Register("leak", func(args ...Value) (Value, error) {
+			return []int{1, 2}, nil
+		})
+		if _, err := engine.Eval(`var ys = leak(); print ys == ys;`); err == nil {
+			t.Errorf("vm=%v: a []int returned by a Go function reached the script", useVM)
+		} else if message := engine.Diagnostics().Errors[0].Message; !strings.Contains(message, "[]int") {
+			t.Errorf("vm=%v: unexpected error %q", useVM, message)
+		}
+
+		engine.Eval(`fun id(x) { return x; }`)
+		if _, err := engine.Call("id", []int{1}); err == nil {
+			t.Errorf("vm=%v: Call passed a []int to the script", useVM)
+		}
+	}
+}
+
+func TestEvalTerminatesTheLastStatement(t *testing.T) {
+	for _, useVM := range []bool{false, true} {
+		engine := newTestEngine(io.Discard)
+		engine.UseVM(useVM)
+		if _, err := engine.Eval(`fun grow(n) { return n * 2; }`); err != nil {
+			t.Fatalf("vm=%v: %v", useVM, err)
+		}
+		value, err := engine.Eval(`grow(1)`)
+		if err != nil || value != int64(2) {
+			t.Errorf("vm=%v: Eval(\"grow(1)\") = %v, %v; want 2", useVM, value, err)
+		}
+		if _, err := engine.EvalNamed("strict", `grow(1)`); err != ErrCompile {
+			t.Errorf("vm=%v: EvalNamed accepted a missing ';': %v", useVM, err)
+		}
+	}
+}
+
+// runScript runs source on a fresh engine, on the VM if useVM is set, and
+// returns what it printed followed by the message of the first error, if
+// any, as "error: message".
+func runScript(source string, useVM bool) string {
+	var out bytes.Buffer
+	engine := newTestEngine(&out)
+	engine.UseVM(useVM)
+	if _, err := engine.Eval(source); err != nil {
+		if errors := engine.Diagnostics().Errors; len(errors) > 0 {
+			out.WriteString("error: " + errors[0].Message + "\n")
+		} else {
+			out.WriteString("error: " + err.Error() + "\n")
+		}
+	}
+	return out.String()
+}
+
+func TestEmbeddingAPI(t *testing.T) {
+	for _, useVM := range []bool{false, true} {
+		var out bytes.Buffer
+		engine := newTestEngine(&out)
+		engine.UseVM(useVM)
+		engine.SetStdin(strings.NewReader("Ada\n"))
+		engine.Define("limit", 10)
+		engine.Register("double", func(args ...Value) (Value, error) {
+			return args[0].(int64) * 2, nil
+		})
+		var handlers []Value
+		engine.Register("on", func(args ...Value) (Value, error) {
+			handlers = append(handlers, args[0])
+			return nil, nil
+		})
+		_, err := engine.Eval(`
+fun grow(n) { return double(n) + limit; }
+fun fail() { return 1 % 0; }
+on(fun (event) { print "got " + event; });
+print "hello " + input();`)
+		if err != nil {
+			t.Fatalf("vm=%v: %v", useVM, err)
+		}
+
+		if result, err := engine.Call("grow", 4); err != nil || result != int64(18) {
+			t.Errorf("vm=%v: grow(4) = %v, %v; want 18", useVM, result, err)
+		}
+		if len(handlers) != 1 {
+			t.Fatalf("vm=%v: got %d handlers, want 1", useVM, len(handlers))
+		}
+		if _, err := engine.CallValue(handlers[0], "click"); err != nil {
+			t.Errorf("vm=%v: CallValue: %v", useVM, err)
+		}
+		if want := "hello Ada\ngot click\n"; out.String() != want {
+			t.Errorf("vm=%v: printed %q, want %q", useVM, out.String(), want)
+		}
+
+		_, err = engine.Call("fail")
+		callError, isCallError := err.(CallError)
+		if !isCallError || callError.Diagnostic == nil || callError.Diagnostic.Line != 3 {
+			t.Errorf("vm=%v: Call(\"fail\") = %#v, want a CallError located on line 3", useVM, err)
+		}
+		for _, name := range []string{"missing", "limit"} {
+			if _, err := engine.Call(name); err == nil {
+				t.Errorf("vm=%v: Call(%q) succeeded", useVM, name)
+			}
+		}
+		if _, err := engine.Call("grow"); err == nil {
+			t.Errorf("vm=%v: Call with too few arguments succeeded", useVM)
+		}
+
+		if value, exists := engine.Lookup("limit"); !exists || value != int64(10) {
+			t.Errorf("vm=%v: Lookup(\"limit\") = %v, %v", useVM, value, exists)
+		}
+		if got, want := strings.Join(engine.Globals(), " "), "double fail grow limit on"; got != want {
+			t.Errorf("vm=%v: Globals() = %q, want %q", useVM, got, want)
+		}
+	}
+}
+
+func TestBackendsShareGlobals(t *testing.T) {
+	var out bytes.Buffer
+	engine := newTestEngine(&out)
+	steps := []struct {
+		useVM  bool
+		source string
+	}{
+		{false, `var a = 1; fun later() { return b; }`},
+		{true, `print a; a = a + 1; var b = 10; fun sum() { return a + b; }`},
+		{false, `print later(); print sum(); b = 0.5;`},
+		{true, `print sum(); print later();`},
+	}
+	for _, step := range steps {
+		engine.UseVM(step.useVM)
+		if _, err := engine.Eval(step.source); err != nil {
+			t.Fatalf("vm=%v: %q: %v", step.useVM, step.source, err)
+		}
+	}
+	if want := "1\n10\n12\n2.5\n0.5\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestSetDecimalContext(t *testing.T) {
+	for _, useVM := range []bool{false, true} {
+		var out bytes.Buffer
+		engine := newTestEngine(&out)
+		engine.UseVM(useVM)
+		if err := engine.SetDecimalContext(4, "down"); err != nil {
+			t.Fatal(err)
+		}
+		engine.Eval(`print 2d / 3; print 2d ** -3;`)
+		for _, bad := range []struct {
+			digits int
+			mode   string
+		}{{0, "up"}, {-1, "up"}, {maxIntegerBits + 1, "up"}, {5, "sideways"}} {
+			if err := engine.SetDecimalContext(bad.digits, bad.mode); err == nil {
+				t.Errorf("SetDecimalContext(%d, %q) succeeded", bad.digits, bad.mode)
+			}
+		}
+		engine.Eval(`print 1d / 3;`)
+		if want := "0.6666\n0.125\n0.3333\n"; out.String() != want {
+			t.Errorf("vm=%v: printed %q, want %q", useVM, out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package pyro
+
+// Environment holds the variables of one scope. The outermost environment,
+// the one with no Enclosing, holds the globals, and stores each of them in a
+// *Global rather than directly in Values.
+type Environment struct {
+	Enclosing *Environment
+	Values    map[string]interface{}
+}
+
+// Global is the storage for one global variable. Both backends share it: the
+// tree-walker finds it by name, while compiled code holds it in its constant
+// pool and so reaches it without a lookup. A name compiled before any value
+// is bound to it gets a Global that is not yet Defined.
+type Global struct {
+	Name    string
+	Value   vmValue
+	Defined bool
+}
+
+func (e Environment) define(name string, value interface{}) {
+	if e.Enclosing == nil {
+		global := e.global(name)
+		global.Value, global.Defined = toVM(value), true
+		return
+	}
+	e.Values[name] = value
+}
+
+// global returns the storage for the global name, adding it if need be.
+func (e Environment) global(name string) *Global {
+	if global, exists := e.Values[name]; exists {
+		return global.(*Global)
+	}
+	global := &Global{Name: name}
+	e.Values[name] = global
+	return global
+}
+
+// lookup returns the value of name in this environment alone.
+func (e Environment) lookup(name string) (interface{}, bool) {
+	value, exists := e.Values[name]
+	if global, isGlobal := value.(*Global); isGlobal {
+		return global.Value.value(), global.Defined
+	}
+	return value, exists
+}
+
+func NewEnvironment() *Environment {
+	return &Environment{
+		Enclosing: nil,
+		Values:    make(map[string]interface{}),
+	}
+}
+func NewEnclosedEnvironment(enclosed *Environment) *Environment {
+	return &Environment{
+		Enclosing: enclosed,
+		Values:    make(map[string]interface{}),
+	}
+}
+
+func (e Environment) get(name Token) (interface{}, error) {
+	if value, exists := e.lookup(name.Lexeme); exists {
+		return value, nil
+	}
+	if e.Enclosing != nil {
+		return e.Enclosing.get(name)
+	}
+	err := NewRunTimeError(name, "Undefined variable '"+name.Lexeme+"'.")
+	return nil, err
+}
+
+func (e Environment) assign(name Token, value interface{}) error {
+	if _, exists := e.lookup(name.Lexeme); exists {
+		e.define(name.Lexeme, value)
+		return nil
+	}
+
+	if e.Enclosing != nil {
+		return e.Enclosing.assign(name, value)
+	}
+
+	err := NewRunTimeError(name, "Undefined variable '"+name.Lexeme+"'.")
+
+	return err
+}
+
+func (e *Environment) ancestor(distance int) *Environment {
+	environment := e
+	for i := 0; i < distance; i++ {
+		environment = environment.Enclosing
+	}
+	return environment
+}
+
+func (e *Environment) getAt(distance int, name string) interface{} {
+	return e.ancestor(distance).Values[name]
+}
+
+func (e *Environment) assignAt(distance int, name Token, value interface{}) {
+	e.ancestor(distance).Values[name.Lexeme] = value
+}
@@ -0,0 +1,227 @@
+package pyro
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type ErrorKind int
+
+const (
+	SCAN_ERROR ErrorKind = iota
+	PARSE_ERROR
+	RESOLVE_ERROR
+	COMPILE_ERROR
+	RUNTIME_ERROR
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case SCAN_ERROR:
+		return "scan"
+	case PARSE_ERROR:
+		return "parse"
+	case RESOLVE_ERROR:
+		return "resolve"
+	case COMPILE_ERROR:
+		return "compile"
+	case RUNTIME_ERROR:
+		return "runtime"
+	default:
+		return "unknown"
+	}
+}
+
+type Severity int
+
+const (
+	ERROR Severity = iota
+	WARNING
+)
+
+func (s Severity) String() string {
+	if s == WARNING {
+		return "Warning"
+	}
+	return "Error"
+}
+
+// Error is a single diagnostic. Column is 1-based and 0 when unknown; Span
+// has no File when the error can't be tied to source text.
+type Error struct {
+	Kind     ErrorKind
+	Severity Severity
+	Line     int
+	Column   int
+	Span     Span
+	Message  string
+	Where    string
+	Token    *Token
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("[line %d] %v%s: %s", e.Line, e.Severity, e.Where, e.Message)
+}
+
+type ParseError struct {
+	Err Error
+}
+
+type RunTimeError struct {
+	Err   Error
+	Token Token
+}
+
+func (rte RunTimeError) Error() string {
+	return rte.Err.Error()
+}
+
+func (pe ParseError) Error() string {
+	return pe.Err.Error()
+}
+
+func NewRunTimeError(token Token, message string) RunTimeError {
+	err := Error{
+		Kind:    RUNTIME_ERROR,
+		Line:    token.Line,
+		Column:  token.Span.Column,
+		Span:    token.Span,
+		Message: message,
+		Token:   &token,
+	}
+
+	return RunTimeError{
+		Err:   err,
+		Token: token,
+	}
+}
+func NewParseError(token Token, message string) ParseError {
+	err := Error{
+		Kind:    PARSE_ERROR,
+		Line:    token.Line,
+		Column:  token.Span.Column,
+		Span:    token.Span,
+		Message: message,
+		Token:   &token,
+	}
+
+	if token.Type == EOF {
+		err.Where = " at end"
+	} else {
+		err.Where = " at '" + token.Lexeme + "'"
+	}
+	return ParseError{Err: err}
+}
+
+func NewError(line int, msg string, where string) Error {
+	return Error{
+		Line:    line,
+		Message: msg,
+		Where:   where,
+	}
+}
+
+// Reporter receives every diagnostic as it is recorded.
+type Reporter interface {
+	Report(err Error)
+}
+
+// WriterReporter prints diagnostics to W, one per line.
+type WriterReporter struct {
+	W io.Writer
+}
+
+func (wr WriterReporter) Report(err Error) {
+	fmt.Fprint(wr.W, err.Render())
+}
+
+// Render formats the error with the offending source line and a caret
+// underline beneath the span:
+//
+//	error: Operands must be a number
+//	 --> script.pyro:3:13
+//	  |
+//	3 | print 1 + "a" + 2;
+//	  |         ^
+func (e *Error) Render() string {
+	file := e.Span.File
+	if file == nil || e.Span.Line == 0 {
+		return strings.ToLower(e.Severity.String()) + ": " + e.Message + "\n"
+	}
+
+	gutter := strconv.Itoa(e.Span.Line)
+	padding := strings.Repeat(" ", len(gutter))
+	header := fmt.Sprintf("%s: %s\n%s--> %s:%d:%d\n", strings.ToLower(e.Severity.String()), e.Message,
+		padding, file.Name, e.Span.Line, e.Span.Column)
+
+	// Programs loaded from .pyroc files carry no source text to quote.
+	if file.Text == "" {
+		return header
+	}
+	line := file.lineText(e.Span.Line)
+
+	// Tabs before the span are kept so the caret lines up with the source.
+	var indent strings.Builder
+	column := 1
+	for _, c := range line {
+		if column >= e.Span.Column {
+			break
+		}
+		if c == '\t' {
+			indent.WriteRune('\t')
+		} else {
+			indent.WriteRune(' ')
+		}
+		column++
+	}
+
+	var out strings.Builder
+	out.WriteString(header)
+	fmt.Fprintf(&out, "%s |\n", padding)
+	fmt.Fprintf(&out, "%s | %s\n", gutter, line)
+	fmt.Fprintf(&out, "%s | %s%s\n", padding, indent.String(), strings.Repeat("^", e.Span.width()))
+	return out.String()
+}
+
+// Diagnostics collects the errors produced by one scanner, parser, resolver
+// and interpreter pipeline and forwards each to an optional Reporter.
+type Diagnostics struct {
+	Errors   []Error
+	Reporter Reporter
+}
+
+func NewDiagnostics(reporter Reporter) *Diagnostics {
+	return &Diagnostics{
+		Errors:   make([]Error, 0),
+		Reporter: reporter,
+	}
+}
+
+// NewStderrDiagnostics returns a collector that also prints to os.Stderr.
+func NewStderrDiagnostics() *Diagnostics {
+	return NewDiagnostics(WriterReporter{W: os.Stderr})
+}
+
+func (d *Diagnostics) report(err Error) {
+	d.Errors = append(d.Errors, err)
+	if d.Reporter != nil {
+		d.Reporter.Report(err)
+	}
+}
+
+// HasErrors reports whether any diagnostic of ERROR severity was recorded.
+func (d *Diagnostics) HasErrors() bool {
+	for _, err := range d.Errors {
+		if err.Severity == ERROR {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Diagnostics) Reset() {
+	d.Errors = make([]Error, 0)
+}
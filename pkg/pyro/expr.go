@@ -0,0 +1,525 @@
+package pyro
+
+import "sync/atomic"
+
+type Expr interface {
+	Accept(visitor ExprVisitor) (interface{}, error)
+	Span() Span
+}
+
+type ExprVisitor interface {
+	VisitBinaryExpr(expr Binary) (interface{}, error)
+	VisitUnaryExpr(expr Unary) (interface{}, error)
+	VisitLiteralExpr(expr Literal) (interface{}, error)
+	VisitGroupingExpr(expr Grouping) (interface{}, error)
+	VisitVariableExpr(expr Variable) (interface{}, error)
+	VisitAssignExpr(expr Assign) (interface{}, error)
+	VisitLogicalExpr(expr Logical) (interface{}, error)
+	VisitCallExpr(expr Call) (interface{}, error)
+	VisitGetExpr(expr Get) (interface{}, error)
+	VisitSetExpr(expr Set) (interface{}, error)
+	VisitThisExpr(expr This) (interface{}, error)
+	VisitSuperExpr(expr Super) (interface{}, error)
+	VisitListExpr(expr List) (interface{}, error)
+	VisitSubscriptExpr(expr Subscript) (interface{}, error)
+	VisitSubscriptSetExpr(expr SubscriptSet) (interface{}, error)
+	VisitMapExpr(expr Map) (interface{}, error)
+	VisitLambdaExpr(expr Lambda) (interface{}, error)
+	VisitInterpolationExpr(expr Interpolation) (interface{}, error)
+	VisitConditionalExpr(expr Conditional) (interface{}, error)
+	VisitCoalesceExpr(expr Coalesce) (interface{}, error)
+	VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, error)
+}
+
+type Binary struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+}
+
+func NewBinary(left Expr, op Token, right Expr) Binary {
+	return Binary{
+		Left:     left,
+		Operator: op,
+		Right:    right,
+	}
+}
+
+type Unary struct {
+	Operator Token
+	Right    Expr
+}
+
+func NewUnary(op Token, right Expr) Unary {
+	return Unary{
+		Operator: op,
+		Right:    right,
+	}
+}
+
+type Literal struct {
+	Value    interface{}
+	Location Span
+}
+
+func NewLiteral(value interface{}, span Span) Literal {
+	return Literal{
+		Value:    value,
+		Location: span,
+	}
+}
+
+type Grouping struct {
+	Expression Expr
+}
+
+func NewGrouping(expr Expr) Grouping {
+	return Grouping{
+		Expression: expr,
+	}
+}
+
+// ExprID identifies a single variable-referencing node. Nodes are plain
+// values, so the resolver keys its scope distances by ID rather than by node.
+type ExprID int64
+
+var lastExprID int64
+
+func newExprID() ExprID {
+	return ExprID(atomic.AddInt64(&lastExprID, 1))
+}
+
+type Variable struct {
+	Name Token
+	ID   ExprID
+}
+
+func NewVariable(name Token) Variable {
+	return Variable{
+		Name: name,
+		ID:   newExprID(),
+	}
+}
+
+type Assign struct {
+	Name  Token
+	Value Expr
+	ID    ExprID
+}
+
+func NewAssign(name Token, value Expr) Assign {
+	return Assign{
+		Name:  name,
+		Value: value,
+		ID:    newExprID(),
+	}
+}
+
+type Logical struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+}
+
+func NewLogical(left Expr, operator Token, right Expr) Logical {
+	return Logical{
+		Left:     left,
+		Operator: operator,
+		Right:    right,
+	}
+}
+
+// Conditional is cond ? then : else. Only the chosen branch is evaluated.
+type Conditional struct {
+	Condition  Expr
+	ThenBranch Expr
+	ElseBranch Expr
+}
+
+func NewConditional(condition Expr, thenBranch Expr, elseBranch Expr) Conditional {
+	return Conditional{
+		Condition:  condition,
+		ThenBranch: thenBranch,
+		ElseBranch: elseBranch,
+	}
+}
+
+// Coalesce is left ?? right, which evaluates right only when left is nil.
+type Coalesce struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+}
+
+func NewCoalesce(left Expr, operator Token, right Expr) Coalesce {
+	return Coalesce{
+		Left:     left,
+		Operator: operator,
+		Right:    right,
+	}
+}
+
+// CompoundAssign is target op= value, or target incremented or decremented
+// by ++ and --, in which case Value is the literal 1. Target is a Variable,
+// Get or Subscript; its object and index are evaluated exactly once. A
+// postfix ++ or -- yields the value the target held before the update.
+type CompoundAssign struct {
+	Target   Expr
+	Operator Token
+	Value    Expr
+	Postfix  bool
+}
+
+func NewCompoundAssign(target Expr, operator Token, value Expr, postfix bool) CompoundAssign {
+	return CompoundAssign{
+		Target:   target,
+		Operator: operator,
+		Value:    value,
+		Postfix:  postfix,
+	}
+}
+
+// compoundOperators maps each compound assignment operator to the binary
+// operator it applies.
+var compoundOperators = map[TokenType]TokenType{
+	PLUSEQ:     PLUS,
+	MINUSEQ:    MINUS,
+	STAREQ:     STAR,
+	SLASHEQ:    SLASH,
+	MODEQ:      MOD,
+	PLUSPLUS:   PLUS,
+	MINUSMINUS: MINUS,
+}
+
+// binaryOperator is Operator retyped as the binary operator it applies, so
+// errors from the operation still point at the += or ++ in the source.
+func (c CompoundAssign) binaryOperator() Token {
+	operator := c.Operator
+	operator.Type = compoundOperators[operator.Type]
+	return operator
+}
+
+type Call struct {
+	Callee Expr
+	Paren Token
+	Arguments []Expr
+}
+
+func NewCall(callee Expr, paren Token, arguments []Expr) Call {
+	return Call{
+		Callee: callee,
+		Paren: paren,
+		Arguments: arguments,
+	}
+}
+
+type Get struct {
+	Object Expr
+	Name   Token
+}
+
+func NewGet(object Expr, name Token) Get {
+	return Get{
+		Object: object,
+		Name:   name,
+	}
+}
+
+type Set struct {
+	Object Expr
+	Name   Token
+	Value  Expr
+}
+
+func NewSet(object Expr, name Token, value Expr) Set {
+	return Set{
+		Object: object,
+		Name:   name,
+		Value:  value,
+	}
+}
+
+type This struct {
+	Keyword Token
+	ID      ExprID
+}
+
+func NewThis(keyword Token) This {
+	return This{
+		Keyword: keyword,
+		ID:      newExprID(),
+	}
+}
+
+type Super struct {
+	Keyword Token
+	Method  Token
+	ID      ExprID
+}
+
+func NewSuper(keyword Token, method Token) Super {
+	return Super{
+		Keyword: keyword,
+		Method:  method,
+		ID:      newExprID(),
+	}
+}
+
+type List struct {
+	Elements []Expr
+	Location Span
+}
+
+func NewList(elements []Expr, span Span) List {
+	return List{
+		Elements: elements,
+		Location: span,
+	}
+}
+
+// Map is a map literal. Keys[i] maps to Values[i]; Brace is the opening '{',
+// which runtime errors about keys point at.
+type Map struct {
+	Brace    Token
+	Keys     []Expr
+	Values   []Expr
+	Location Span
+}
+
+func NewMap(brace Token, keys []Expr, values []Expr, span Span) Map {
+	return Map{
+		Brace:    brace,
+		Keys:     keys,
+		Values:   values,
+		Location: span,
+	}
+}
+
+// Subscript is object[index]. Bracket is the closing ']', which runtime
+// errors point at.
+type Subscript struct {
+	Object  Expr
+	Bracket Token
+	Index   Expr
+}
+
+func NewSubscript(object Expr, bracket Token, index Expr) Subscript {
+	return Subscript{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+	}
+}
+
+type SubscriptSet struct {
+	Object  Expr
+	Bracket Token
+	Index   Expr
+	Value   Expr
+}
+
+func NewSubscriptSet(object Expr, bracket Token, index Expr, value Expr) SubscriptSet {
+	return SubscriptSet{
+		Object:  object,
+		Bracket: bracket,
+		Index:   index,
+		Value:   value,
+	}
+}
+
+// Lambda is an anonymous function, written fun (a) { ... } or (a) => expr.
+// The arrow form's body is a single return statement.
+type Lambda struct {
+	Declaration Function
+}
+
+func NewLambda(declaration Function) Lambda {
+	return Lambda{
+		Declaration: declaration,
+	}
+}
+
+// Interpolation is a string literal with embedded ${...} expressions. Parts
+// holds the literal text and the expressions in order; each part is
+// stringified and the results joined.
+type Interpolation struct {
+	Parts    []Expr
+	Location Span
+}
+
+func NewInterpolation(parts []Expr, span Span) Interpolation {
+	return Interpolation{
+		Parts:    parts,
+		Location: span,
+	}
+}
+
+func (b Binary) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitBinaryExpr(b)
+}
+
+func (u Unary) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitUnaryExpr(u)
+}
+
+func (u Literal) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitLiteralExpr(u)
+}
+
+func (g Grouping) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitGroupingExpr(g)
+}
+
+func (v Variable) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitVariableExpr(v)
+}
+func (a Assign) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitAssignExpr(a)
+}
+
+func (l Logical) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitLogicalExpr(l)
+}
+
+func (c Call) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitCallExpr(c)
+}
+
+func (g Get) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitGetExpr(g)
+}
+
+func (s Set) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSetExpr(s)
+}
+
+func (t This) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitThisExpr(t)
+}
+
+func (s Super) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSuperExpr(s)
+}
+
+func (l List) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitListExpr(l)
+}
+
+func (m Map) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitMapExpr(m)
+}
+
+func (s Subscript) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSubscriptExpr(s)
+}
+
+func (s SubscriptSet) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitSubscriptSetExpr(s)
+}
+
+func (l Lambda) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitLambdaExpr(l)
+}
+
+func (i Interpolation) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitInterpolationExpr(i)
+}
+
+func (c Conditional) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitConditionalExpr(c)
+}
+
+func (c Coalesce) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitCoalesceExpr(c)
+}
+
+func (c CompoundAssign) Accept(visitor ExprVisitor) (interface{}, error) {
+	return visitor.VisitCompoundAssignExpr(c)
+}
+
+func (b Binary) Span() Span {
+	return b.Left.Span().To(b.Right.Span())
+}
+
+func (u Unary) Span() Span {
+	return u.Operator.Span.To(u.Right.Span())
+}
+
+func (l Literal) Span() Span {
+	return l.Location
+}
+
+func (g Grouping) Span() Span {
+	return g.Expression.Span()
+}
+
+func (v Variable) Span() Span {
+	return v.Name.Span
+}
+
+func (a Assign) Span() Span {
+	return a.Name.Span.To(a.Value.Span())
+}
+
+func (l Logical) Span() Span {
+	return l.Left.Span().To(l.Right.Span())
+}
+
+func (c Call) Span() Span {
+	return c.Callee.Span().To(c.Paren.Span)
+}
+
+func (g Get) Span() Span {
+	return g.Object.Span().To(g.Name.Span)
+}
+
+func (s Set) Span() Span {
+	return s.Object.Span().To(s.Value.Span())
+}
+
+func (t This) Span() Span {
+	return t.Keyword.Span
+}
+
+func (s Super) Span() Span {
+	return s.Keyword.Span.To(s.Method.Span)
+}
+
+func (l List) Span() Span {
+	return l.Location
+}
+
+func (m Map) Span() Span {
+	return m.Location
+}
+
+func (s Subscript) Span() Span {
+	return s.Object.Span().To(s.Bracket.Span)
+}
+
+func (s SubscriptSet) Span() Span {
+	return s.Object.Span().To(s.Value.Span())
+}
+
+func (l Lambda) Span() Span {
+	return l.Declaration.Location
+}
+
+func (i Interpolation) Span() Span {
+	return i.Location
+}
+
+func (c Conditional) Span() Span {
+	return c.Condition.Span().To(c.ElseBranch.Span())
+}
+
+func (c Coalesce) Span() Span {
+	return c.Left.Span().To(c.Right.Span())
+}
+
+func (c CompoundAssign) Span() Span {
+	if c.Operator.Span.Start < c.Target.Span().Start {
+		return c.Operator.Span.To(c.Target.Span())
+	}
+	return c.Target.Span().To(c.Value.Span())
+}
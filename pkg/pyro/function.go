@@ -0,0 +1,64 @@
+package pyro
+
+type PyroFunction struct {
+	Declaration Function
+	Closure     *Environment
+	// Locals are the scope distances resolved for the program that declared
+	// the function; see Interpreter.Locals.
+	Locals        map[ExprID]int
+	IsInitializer bool
+}
+
+func NewPyroFunction(declaration Function, closure *Environment, locals map[ExprID]int, isInitializer bool) *PyroFunction {
+	return &PyroFunction{
+		Declaration:   declaration,
+		Closure:       closure,
+		Locals:        locals,
+		IsInitializer: isInitializer,
+	}
+}
+
+func (pf *PyroFunction) Arity() int {
+	return len(pf.Declaration.Params)
+}
+
+func (pf *PyroFunction) toString() string {
+	return "<fn " + pf.Declaration.Name.Lexeme + ">"
+}
+
+// bind returns a copy of the method whose closure defines "this" as instance.
+func (pf *PyroFunction) bind(instance *PyroInstance) Callable {
+	environment := NewEnclosedEnvironment(pf.Closure)
+	environment.define("this", instance)
+	return NewPyroFunction(pf.Declaration, environment, pf.Locals, pf.IsInitializer)
+}
+
+func (pf *PyroFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	if interpreter.depth+1 >= maxFrames {
+		return nil, NativeError{Message: "Stack overflow."}
+	}
+	environment := NewEnclosedEnvironment(pf.Closure)
+
+	for i := 0; i < len(pf.Declaration.Params); i++ {
+		environment.define(pf.Declaration.Params[i].Lexeme, arguments[i])
+	}
+
+	enclosingLocals := interpreter.Locals
+	interpreter.Locals = pf.Locals
+	interpreter.depth++
+	err := interpreter.executeBlock(pf.Declaration.Body, environment)
+	interpreter.depth--
+	interpreter.Locals = enclosingLocals
+	if err != nil {
+		return nil, err
+	}
+
+	value := interpreter.returnValue
+	interpreter.returning = false
+	interpreter.returnValue = nil
+
+	if pf.IsInitializer {
+		return pf.Closure.getAt(0, "this"), nil
+	}
+	return value, nil
+}
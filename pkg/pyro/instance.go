@@ -0,0 +1,42 @@
+package pyro
+
+type PyroInstance struct {
+	Class  *PyroClass
+	Fields map[string]interface{}
+}
+
+func NewPyroInstance(class *PyroClass) *PyroInstance {
+	return &PyroInstance{
+		Class:  class,
+		Fields: make(map[string]interface{}),
+	}
+}
+
+func (pi *PyroInstance) toString() string {
+	return pi.Class.Name + " instance"
+}
+
+func (pi *PyroInstance) get(name Token) (interface{}, error) {
+	if value, exists := pi.lookup(name.Lexeme); exists {
+		return value, nil
+	}
+
+	err := NewRunTimeError(name, "Undefined property '"+name.Lexeme+"'.")
+	return nil, err
+}
+
+// lookup finds a field, or failing that a method bound to this instance.
+func (pi *PyroInstance) lookup(name string) (interface{}, bool) {
+	if value, exists := pi.Fields[name]; exists {
+		return value, true
+	}
+
+	if method, exists := pi.Class.findMethod(name); exists {
+		return method.bind(pi), true
+	}
+	return nil, false
+}
+
+func (pi *PyroInstance) set(name Token, value interface{}) {
+	pi.Fields[name.Lexeme] = value
+}
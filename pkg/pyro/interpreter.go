@@ -0,0 +1,767 @@
+package pyro
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type Interpreter struct {
+	Environment *Environment
+	Globals     *Environment
+	// Locals holds the scope distances for the program being run. Each
+	// function keeps the map of the program that declared it, so a program's
+	// map is dropped once nothing it defined is reachable.
+	Locals      map[ExprID]int
+	Diagnostics *Diagnostics
+	Stdin       *bufio.Reader
+	// Stdout receives the output of print statements on both backends.
+	Stdout io.Writer
+	// decimals is how both backends round decimal division.
+	decimals decimalContext
+
+	// returning is set by a return statement and unwinds every enclosing
+	// block and loop until the surrounding PyroFunction.Call clears it.
+	returning   bool
+	returnValue interface{}
+	// breaking and continuing likewise unwind blocks up to the innermost
+	// loop, which clears them.
+	breaking   bool
+	continuing bool
+	// depth counts the function calls in progress; see maxFrames.
+	depth int
+}
+
+func NewInterpreter(diagnostics *Diagnostics) *Interpreter {
+	globals := NewEnvironment()
+	definePrelude(globals)
+	return &Interpreter{
+		Environment: globals,
+		Globals:     globals,
+		Locals:      make(map[ExprID]int),
+		Diagnostics: diagnostics,
+		Stdin:       bufio.NewReader(os.Stdin),
+		Stdout:      os.Stdout,
+		decimals:    defaultDecimalContext,
+	}
+}
+
+func (a *Interpreter) interpret(statements []Stmt) error {
+	for _, statement := range statements {
+		err := a.execute(statement)
+		if err != nil {
+			a.runtimeError(err)
+			return err
+		}
+	}
+	return nil
+}
+
+// interpretExpression evaluates a top-level expression, reporting any runtime
+// error the same way interpret does.
+func (a *Interpreter) interpretExpression(expr Expr) (interface{}, error) {
+	value, err := a.evalute(expr)
+	if err != nil {
+		a.runtimeError(err)
+		return nil, err
+	}
+	return value, nil
+}
+
+// runtimeError is the single place runtime errors reach the diagnostics sink;
+// everything below interpret only returns them.
+func (a *Interpreter) runtimeError(err error) {
+	if rtErr, isRunTime := err.(RunTimeError); isRunTime {
+		a.Diagnostics.report(rtErr.Err)
+	}
+}
+
+func (a *Interpreter) execute(stmt Stmt) error {
+	return stmt.Accept(a)
+}
+func stringify(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		return formatFloat(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case *big.Int:
+		return v.String()
+	case *PyroDecimal:
+		return v.String()
+	case *PyroFunction:
+		return v.toString()
+	case *NativeFunction:
+		return v.toString()
+	case *PyroClass:
+		return v.toString()
+	case *PyroInstance:
+		return v.toString()
+	case *Closure:
+		return v.toString()
+	case *BoundMethod:
+		return v.toString()
+	case *PyroList:
+		return v.toString()
+	case *PyroMap:
+		return v.toString()
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
+func (a *Interpreter) evalute(expr Expr) (interface{}, error) {
+	return expr.Accept(a)
+}
+
+func (a *Interpreter) VisitCallExpr(expr Call) (interface{}, error) {
+	callee, err := a.evalute(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+
+	arguments := make([]interface{}, 0)
+	for _, argument := range expr.Arguments {
+		eval, err := a.evalute(argument)
+		if err != nil {
+			return nil, err
+		}
+
+		arguments = append(arguments, eval)
+	}
+
+	function, isCallable := callee.(Callable)
+	if !isCallable {
+		rtErr := NewRunTimeError(expr.Paren, "Can only call functions and classes.")
+		return nil, rtErr
+	}
+	if function.Arity() >= 0 && len(arguments) != function.Arity() {
+		rtErr := NewRunTimeError(expr.Paren, "Expected "+strconv.Itoa(function.Arity())+" arguments but got "+strconv.Itoa(len(arguments)))
+		return nil, rtErr
+	}
+
+	value, err := function.Call(a, arguments)
+	if nativeErr, isNative := err.(NativeError); isNative {
+		rtErr := NewRunTimeError(expr.Paren, nativeErr.Message)
+		return nil, rtErr
+	}
+	return value, err
+}
+
+func (a *Interpreter) VisitFunctionStmt(stmt Function) error {
+	function := NewPyroFunction(stmt, a.Environment, a.Locals, false)
+	a.Environment.define(function.Declaration.Name.Lexeme, function)
+	return nil
+}
+
+func (a *Interpreter) VisitClassStmt(stmt Class) error {
+	var superclass *PyroClass
+	if stmt.Superclass != nil {
+		value, err := a.evalute(*stmt.Superclass)
+		if err != nil {
+			return err
+		}
+
+		class, isClass := value.(*PyroClass)
+		if !isClass {
+			rtErr := NewRunTimeError(stmt.Superclass.Name, "Superclass must be a class.")
+			return rtErr
+		}
+		superclass = class
+	}
+
+	a.Environment.define(stmt.Name.Lexeme, nil)
+
+	if superclass != nil {
+		a.Environment = NewEnclosedEnvironment(a.Environment)
+		a.Environment.define("super", superclass)
+	}
+
+	methods := make(map[string]Method)
+	for _, method := range stmt.Methods {
+		methods[method.Name.Lexeme] = NewPyroFunction(method, a.Environment, a.Locals, method.Name.Lexeme == "init")
+	}
+
+	class := NewPyroClass(stmt.Name.Lexeme, superclass, methods)
+
+	if superclass != nil {
+		a.Environment = a.Environment.Enclosing
+	}
+	return a.Environment.assign(stmt.Name, class)
+}
+
+func (a *Interpreter) VisitGetExpr(expr Get) (interface{}, error) {
+	object, err := a.evalute(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	return getProperty(expr.Name, object)
+}
+
+func getProperty(name Token, object interface{}) (interface{}, error) {
+	if instance, isInstance := object.(*PyroInstance); isInstance {
+		return instance.get(name)
+	}
+	if collection, isCollection := object.(collection); isCollection {
+		if method, exists := collection.method(name.Lexeme); exists {
+			return method, nil
+		}
+		return nil, NewRunTimeError(name, "Undefined property '"+name.Lexeme+"'.")
+	}
+
+	rtErr := NewRunTimeError(name, "Only instances have properties.")
+	return nil, rtErr
+}
+
+func (a *Interpreter) VisitSetExpr(expr Set) (interface{}, error) {
+	object, err := a.evalute(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	instance, isInstance := object.(*PyroInstance)
+	if !isInstance {
+		rtErr := NewRunTimeError(expr.Name, "Only instances have fields.")
+		return nil, rtErr
+	}
+
+	value, err := a.evalute(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	instance.set(expr.Name, value)
+	return value, nil
+}
+
+func (a *Interpreter) VisitListExpr(expr List) (interface{}, error) {
+	elements := make([]interface{}, 0, len(expr.Elements))
+	for _, element := range expr.Elements {
+		value, err := a.evalute(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, value)
+	}
+	return NewPyroList(elements), nil
+}
+
+func (a *Interpreter) VisitMapExpr(expr Map) (interface{}, error) {
+	keys := make([]interface{}, len(expr.Keys))
+	values := make([]interface{}, len(expr.Values))
+	for i := range expr.Keys {
+		key, err := a.evalute(expr.Keys[i])
+		if err != nil {
+			return nil, err
+		}
+		value, err := a.evalute(expr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		keys[i], values[i] = key, value
+	}
+	return buildMap(expr.Brace, keys, values)
+}
+
+func (a *Interpreter) VisitSubscriptExpr(expr Subscript) (interface{}, error) {
+	object, err := a.evalute(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := a.evalute(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	return getIndex(expr.Bracket, object, index)
+}
+
+func (a *Interpreter) VisitSubscriptSetExpr(expr SubscriptSet) (interface{}, error) {
+	object, err := a.evalute(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	index, err := a.evalute(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	value, err := a.evalute(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	return value, setIndex(expr.Bracket, object, index, value)
+}
+
+func (a *Interpreter) VisitSuperExpr(expr Super) (interface{}, error) {
+	distance := a.Locals[expr.ID]
+	superclass := a.Environment.getAt(distance, "super").(*PyroClass)
+
+	// "this" is always bound one environment inside the one holding "super".
+	object := a.Environment.getAt(distance-1, "this").(*PyroInstance)
+
+	method, exists := superclass.findMethod(expr.Method.Lexeme)
+	if !exists {
+		rtErr := NewRunTimeError(expr.Method, "Undefined property '"+expr.Method.Lexeme+"'.")
+		return nil, rtErr
+	}
+	return method.bind(object), nil
+}
+
+func (a *Interpreter) VisitThisExpr(expr This) (interface{}, error) {
+	return a.lookUpVariable(expr.Keyword, expr.ID)
+}
+
+func (a *Interpreter) VisitWhileStmt(expr While) error {
+	cond, err := a.evalute(expr.Condition)
+	if err != nil {
+		return err
+	}
+	for isTruthy(cond) {
+		err = a.execute(expr.Body)
+		if err != nil {
+			return err
+		}
+		if a.returning {
+			return nil
+		}
+		if a.breaking {
+			a.breaking = false
+			return nil
+		}
+		a.continuing = false
+
+		if expr.Increment != nil {
+			_, err = a.evalute(*expr.Increment)
+			if err != nil {
+				return err
+			}
+		}
+		cond, err = a.evalute(expr.Condition)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Interpreter) VisitLogicalExpr(expr Logical) (interface{}, error) {
+	left, err := a.evalute(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	if expr.Operator.Type == OR {
+		if isTruthy(left) {
+			return left, nil
+		}
+	} else {
+		if !isTruthy(left) {
+			return left, nil
+		}
+	}
+
+	return a.evalute(expr.Right)
+}
+
+func (a *Interpreter) VisitConditionalExpr(expr Conditional) (interface{}, error) {
+	condition, err := a.evalute(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if isTruthy(condition) {
+		return a.evalute(expr.ThenBranch)
+	}
+	return a.evalute(expr.ElseBranch)
+}
+
+func (a *Interpreter) VisitCoalesceExpr(expr Coalesce) (interface{}, error) {
+	left, err := a.evalute(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+
+	if left != nil {
+		return left, nil
+	}
+	return a.evalute(expr.Right)
+}
+
+func (a *Interpreter) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, error) {
+	old, store, err := a.target(expr.Target)
+	if err != nil {
+		return nil, err
+	}
+	value, err := a.evalute(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	result, err := binaryOp(expr.binaryOperator(), old, value, a.decimals)
+	if err != nil {
+		return nil, err
+	}
+	if err := store(result); err != nil {
+		return nil, err
+	}
+
+	if expr.Postfix {
+		return old, nil
+	}
+	return result, nil
+}
+
+// target evaluates the object and index of an assignment target once and
+// returns the value it currently holds, along with a function that stores a
+// new value into the same place.
+func (a *Interpreter) target(expr Expr) (interface{}, func(interface{}) error, error) {
+	switch target := expr.(type) {
+	case Variable:
+		old, err := a.lookUpVariable(target.Name, target.ID)
+		return old, func(value interface{}) error {
+			if distance, isLocal := a.Locals[target.ID]; isLocal {
+				a.Environment.assignAt(distance, target.Name, value)
+				return nil
+			}
+			return a.Globals.assign(target.Name, value)
+		}, err
+	case Get:
+		object, err := a.evalute(target.Object)
+		if err != nil {
+			return nil, nil, err
+		}
+		old, err := getProperty(target.Name, object)
+		return old, func(value interface{}) error {
+			instance, isInstance := object.(*PyroInstance)
+			if !isInstance {
+				return NewRunTimeError(target.Name, "Only instances have fields.")
+			}
+			instance.set(target.Name, value)
+			return nil
+		}, err
+	}
+
+	target := expr.(Subscript)
+	object, err := a.evalute(target.Object)
+	if err != nil {
+		return nil, nil, err
+	}
+	index, err := a.evalute(target.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+	old, err := getIndex(target.Bracket, object, index)
+	return old, func(value interface{}) error {
+		return setIndex(target.Bracket, object, index, value)
+	}, err
+}
+
+func (a *Interpreter) VisitIfStmt(stmt If) error {
+	cond, err := a.evalute(stmt.Condition)
+	if err != nil {
+		return err
+	}
+
+	if isTruthy(cond) {
+		err = a.execute(stmt.ThenBranch)
+	} else if stmt.ElseBranch != nil {
+		err = a.execute(*stmt.ElseBranch)
+	}
+
+	return err
+}
+
+func (a *Interpreter) VisitBlockStmt(stmt Block) error {
+	err := a.executeBlock(stmt.Statements, NewEnclosedEnvironment(a.Environment))
+	return err
+}
+
+func (a *Interpreter) executeBlock(statements []Stmt, environment *Environment) error {
+	previous := a.Environment
+	a.Environment = environment
+
+	for _, statement := range statements {
+		err := a.execute(statement)
+		if err != nil {
+			a.Environment = previous
+			return err
+		}
+		if a.returning || a.breaking || a.continuing {
+			break
+		}
+	}
+	a.Environment = previous
+	return nil
+}
+
+func (a *Interpreter) VisitInterpolationExpr(expr Interpolation) (interface{}, error) {
+	var text strings.Builder
+	for _, part := range expr.Parts {
+		value, err := a.evalute(part)
+		if err != nil {
+			return nil, err
+		}
+		text.WriteString(stringify(value))
+	}
+	return text.String(), nil
+}
+
+func (a *Interpreter) VisitLambdaExpr(expr Lambda) (interface{}, error) {
+	return NewPyroFunction(expr.Declaration, a.Environment, a.Locals, false), nil
+}
+
+func (a *Interpreter) VisitBreakStmt(stmt Break) error {
+	a.breaking = true
+	return nil
+}
+
+func (a *Interpreter) VisitContinueStmt(stmt Continue) error {
+	a.continuing = true
+	return nil
+}
+
+func (a *Interpreter) VisitReturnStmt(stmt Return) error {
+	var value interface{}
+	if stmt.Value != nil {
+		var err error
+		value, err = a.evalute(*stmt.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.returning = true
+	a.returnValue = value
+	return nil
+}
+
+func (a *Interpreter) VisitAssignExpr(expr Assign) (interface{}, error) {
+	value, err := a.evalute(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if distance, isLocal := a.Locals[expr.ID]; isLocal {
+		a.Environment.assignAt(distance, expr.Name, value)
+	} else {
+		err = a.Globals.assign(expr.Name, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return value, nil
+}
+
+func (a *Interpreter) VisitVarStmt(stmt Var) error {
+	var value interface{}
+	var err error
+	if stmt.Initalizer != nil {
+		value, err = a.evalute(*stmt.Initalizer)
+		if err != nil {
+			return err
+		}
+	}
+	a.Environment.define(stmt.Name.Lexeme, value)
+	return nil
+}
+
+func (a *Interpreter) VisitVariableExpr(expr Variable) (interface{}, error) {
+	return a.lookUpVariable(expr.Name, expr.ID)
+}
+
+func (a *Interpreter) lookUpVariable(name Token, id ExprID) (interface{}, error) {
+	if distance, isLocal := a.Locals[id]; isLocal {
+		return a.Environment.getAt(distance, name.Lexeme), nil
+	}
+	return a.Globals.get(name)
+}
+
+func (a *Interpreter) VisitExpressionStmt(stmt Expression) error {
+	_, err := a.evalute(stmt.Expression)
+	return err
+}
+
+func (a *Interpreter) VisitPrintStmt(stmt Print) error {
+	value, err := a.evalute(stmt.Expression)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(a.Stdout, stringify(value))
+	return nil
+}
+
+func (a *Interpreter) VisitLiteralExpr(expr Literal) (interface{}, error) {
+	return expr.Value, nil
+}
+
+func (a *Interpreter) VisitGroupingExpr(expr Grouping) (interface{}, error) {
+	return a.evalute(expr.Expression)
+}
+
+func (a *Interpreter) VisitUnaryExpr(expr Unary) (interface{}, error) {
+	right, err := a.evalute(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	return unaryOp(expr.Operator, right)
+}
+
+// unaryOp applies a prefix operator. It is shared by the tree-walker and the
+// bytecode VM so both backends agree on semantics and error messages.
+func unaryOp(operator Token, right interface{}) (interface{}, error) {
+	switch operator.Type {
+	case NOT:
+		return !isTruthy(right), nil
+	case MINUS:
+		return negate(operator, right)
+	case TILDE:
+		return complement(operator, right)
+	}
+
+	//unreachable
+	return nil, nil
+}
+
+func (a *Interpreter) VisitBinaryExpr(expr Binary) (interface{}, error) {
+	left, err := a.evalute(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	right, err := a.evalute(expr.Right)
+	if err != nil {
+		return nil, err
+	}
+
+	return binaryOp(expr.Operator, left, right, a.decimals)
+}
+
+// binaryOp applies an infix operator; see unaryOp. Decimal division rounds
+// as decimals says.
+func binaryOp(operator Token, left interface{}, right interface{}, decimals decimalContext) (interface{}, error) {
+	switch operator.Type {
+	case GT, GE, LT, LE:
+		return compare(operator, left, right)
+	case NE:
+		return !isEqual(left, right), nil
+	case EQEQ:
+		return isEqual(left, right), nil
+	case PLUS:
+		lStr, lIsStr := left.(string)
+		rStr, rIsStr := right.(string)
+
+		if lIsStr && rIsStr {
+			return lStr + rStr, nil
+		}
+
+		if isNumber(left) && isNumber(right) {
+			return arithmetic(operator, left, right, decimals)
+		}
+
+		err := NewRunTimeError(operator, "Operands must be two nums or two strings")
+		return nil, err
+
+	case MINUS, STAR, SLASH, MOD, SLASHSLASH, STARSTAR:
+		return arithmetic(operator, left, right, decimals)
+	case AMP, PIPE, CARET, LTLT, GTGT:
+		return bitwise(operator, left, right)
+	}
+
+	//unreachable
+	return nil, nil
+}
+
+// getIndex implements object[index] for both backends. Errors are reported
+// at bracket.
+func getIndex(bracket Token, object interface{}, index interface{}) (interface{}, error) {
+	switch collection := object.(type) {
+	case *PyroList:
+		i, err := collection.position(index, len(collection.Elements)-1)
+		if err != nil {
+			return nil, NewRunTimeError(bracket, err.Error())
+		}
+		return collection.Elements[i], nil
+	case *PyroMap:
+		if !isHashable(index) {
+			return nil, NewRunTimeError(bracket, errUnhashable.Message)
+		}
+		value, exists := collection.Get(index)
+		if !exists {
+			return nil, NewRunTimeError(bracket, "Undefined key "+formatElement(index, nil)+".")
+		}
+		return value, nil
+	}
+	return nil, NewRunTimeError(bracket, "Only lists and maps can be indexed.")
+}
+
+// setIndex implements object[index] = value; see getIndex.
+func setIndex(bracket Token, object interface{}, index interface{}, value interface{}) error {
+	switch collection := object.(type) {
+	case *PyroList:
+		i, err := collection.position(index, len(collection.Elements)-1)
+		if err != nil {
+			return NewRunTimeError(bracket, err.Error())
+		}
+		collection.Elements[i] = value
+		return nil
+	case *PyroMap:
+		if !isHashable(index) {
+			return NewRunTimeError(bracket, errUnhashable.Message)
+		}
+		collection.Set(index, value)
+		return nil
+	}
+	return NewRunTimeError(bracket, "Only lists and maps can be indexed.")
+}
+
+// floatMod is % on floats. Like //, it rounds the quotient toward negative
+// infinity, so a nonzero result takes the sign of the divisor. Operands that
+// are whole numbers, by far the common case in scripts, skip math.Mod.
+func floatMod(l float64, r float64) float64 {
+	const exact = 1 << 53
+	li, ri := int64(l), int64(r)
+	var result float64
+	if float64(li) != l || float64(ri) != r || ri == 0 || li <= -exact || li >= exact || ri <= -exact || ri >= exact {
+		result = math.Mod(l, r)
+	} else {
+		result = float64(li % ri)
+	}
+	if result == 0 {
+		return math.Copysign(0, r)
+	}
+	if (result < 0) != (r < 0) {
+		result += r
+	}
+	return result
+}
+
+func isEqual(a interface{}, b interface{}) bool {
+	if a == nil && b == nil {
+		return true
+	}
+	if a == nil {
+		return false
+	}
+	if isNumber(a) && isNumber(b) {
+		order, ordered := compareNumbers(a, b)
+		return ordered && order == 0
+	}
+
+	return a == b
+}
+
+func isTruthy(obj interface{}) bool {
+	if obj == nil {
+		return false
+	}
+	if b, isBool := obj.(bool); isBool {
+		return b
+	}
+	return true
+
+}
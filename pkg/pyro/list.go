@@ -0,0 +1,304 @@
+package pyro
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PyroList is the value of a list literal. Lists are mutable and shared by
+// reference, like instances.
+type PyroList struct {
+	Elements []interface{}
+}
+
+func NewPyroList(elements []interface{}) *PyroList {
+	return &PyroList{
+		Elements: elements,
+	}
+}
+
+// toString formats the list the way it would be written as a literal. A list
+// that contains itself shows up as [...] where it repeats.
+func (pl *PyroList) toString() string {
+	return pl.format(make(map[interface{}]bool))
+}
+
+func (pl *PyroList) format(seen map[interface{}]bool) string {
+	if seen[pl] {
+		return "[...]"
+	}
+	seen[pl] = true
+	defer delete(seen, pl)
+
+	parts := make([]string, len(pl.Elements))
+	for i, element := range pl.Elements {
+		parts[i] = formatElement(element, seen)
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// formatElement stringifies a value held inside a collection, quoting strings
+// so ["1"] and [1] print differently.
+func formatElement(value interface{}, seen map[interface{}]bool) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	case *PyroList:
+		return v.format(seen)
+	case *PyroMap:
+		return v.format(seen)
+	}
+	return stringify(value)
+}
+
+// position converts a script index into a position in the list. Negative
+// indices count back from the end; limit is the largest position allowed,
+// which is one past the end for insert.
+func (pl *PyroList) position(index interface{}, limit int) (int, error) {
+	if !isNumber(index) {
+		return 0, NativeError{Message: "List index must be a number."}
+	}
+	num, isSmall := index.(int64)
+	if !isInteger(index) {
+		return 0, NativeError{Message: "List index must be an integer."}
+	}
+	if num < 0 {
+		num += int64(len(pl.Elements))
+	}
+	if !isSmall || num < 0 || num > int64(limit) {
+		return 0, NativeError{Message: "List index out of range."}
+	}
+	return int(num), nil
+}
+
+// method returns the built-in method name bound to this list.
+func (pl *PyroList) method(name string) (*NativeFunction, bool) {
+	switch name {
+	case "push":
+		return NewNativeFunction(name, 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			pl.Elements = append(pl.Elements, arguments[0])
+			return nil, nil
+		}), true
+	case "pop":
+		return NewNativeFunction(name, 0, func(_ *Interpreter, _ []interface{}) (interface{}, error) {
+			if len(pl.Elements) == 0 {
+				return nil, NativeError{Message: "Can't pop from an empty list."}
+			}
+			last := pl.Elements[len(pl.Elements)-1]
+			pl.Elements = pl.Elements[:len(pl.Elements)-1]
+			return last, nil
+		}), true
+	case "insert":
+		return NewNativeFunction(name, 2, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			i, err := pl.position(arguments[0], len(pl.Elements))
+			if err != nil {
+				return nil, err
+			}
+			pl.Elements = append(pl.Elements, nil)
+			copy(pl.Elements[i+1:], pl.Elements[i:])
+			pl.Elements[i] = arguments[1]
+			return nil, nil
+		}), true
+	case "remove":
+		return NewNativeFunction(name, 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			i, err := pl.position(arguments[0], len(pl.Elements)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed := pl.Elements[i]
+			pl.Elements = append(pl.Elements[:i], pl.Elements[i+1:]...)
+			return removed, nil
+		}), true
+	case "slice":
+		return NewNativeFunction(name, -1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(arguments) < 1 || len(arguments) > 2 {
+				return nil, NativeError{Message: "Expected 1 or 2 arguments but got " + strconv.Itoa(len(arguments))}
+			}
+			start, err := pl.bound(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			end := len(pl.Elements)
+			if len(arguments) == 2 {
+				end, err = pl.bound(arguments[1])
+				if err != nil {
+					return nil, err
+				}
+			}
+			elements := make([]interface{}, 0)
+			if start < end {
+				elements = append(elements, pl.Elements[start:end]...)
+			}
+			return NewPyroList(elements), nil
+		}), true
+	case "map":
+		return NewNativeFunction(name, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			function, err := toFunction(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			elements := make([]interface{}, len(pl.Elements))
+			for i, element := range pl.Elements {
+				value, err := callFunction(interpreter, function, element)
+				if err != nil {
+					return nil, err
+				}
+				elements[i] = value
+			}
+			return NewPyroList(elements), nil
+		}), true
+	case "filter":
+		return NewNativeFunction(name, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			function, err := toFunction(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			elements := make([]interface{}, 0)
+			for _, element := range pl.Elements {
+				keep, err := callFunction(interpreter, function, element)
+				if err != nil {
+					return nil, err
+				}
+				if isTruthy(keep) {
+					elements = append(elements, element)
+				}
+			}
+			return NewPyroList(elements), nil
+		}), true
+	case "reduce":
+		return NewNativeFunction(name, -1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(arguments) < 1 || len(arguments) > 2 {
+				return nil, NativeError{Message: "Expected 1 or 2 arguments but got " + strconv.Itoa(len(arguments))}
+			}
+			function, err := toFunction(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			elements := pl.Elements
+			var accumulator interface{}
+			if len(arguments) == 2 {
+				accumulator = arguments[1]
+			} else if len(elements) == 0 {
+				return nil, NativeError{Message: "Can't reduce an empty list without an initial value."}
+			} else {
+				accumulator, elements = elements[0], elements[1:]
+			}
+			for _, element := range elements {
+				value, err := callFunction(interpreter, function, accumulator, element)
+				if err != nil {
+					return nil, err
+				}
+				accumulator = value
+			}
+			return accumulator, nil
+		}), true
+	case "sort":
+		return NewNativeFunction(name, -1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(arguments) > 1 {
+				return nil, NativeError{Message: "Expected 0 or 1 arguments but got " + strconv.Itoa(len(arguments))}
+			}
+			compare := compareValues
+			if len(arguments) == 1 {
+				function, err := toFunction(arguments[0])
+				if err != nil {
+					return nil, err
+				}
+				compare = func(a interface{}, b interface{}) (int, error) {
+					order, err := callFunction(interpreter, function, a, b)
+					if err != nil {
+						return 0, err
+					}
+					if !isNumber(order) {
+						return 0, NativeError{Message: "Sort comparator must return a number."}
+					}
+					sign, _ := compareNumbers(order, int64(0))
+					return sign, nil
+				}
+			}
+
+			// The comparator may change the list, so a copy is sorted and
+			// stored only once the sort has succeeded.
+			elements := append([]interface{}(nil), pl.Elements...)
+			var failure error
+			sort.SliceStable(elements, func(i int, j int) bool {
+				if failure != nil {
+					return false
+				}
+				order, err := compare(elements[i], elements[j])
+				if err != nil {
+					failure = err
+				}
+				return order < 0
+			})
+			if failure != nil {
+				return nil, failure
+			}
+			if len(pl.Elements) != len(elements) {
+				return nil, NativeError{Message: "List changed size during sort."}
+			}
+			pl.Elements = elements
+			return nil, nil
+		}), true
+	}
+	return nil, false
+}
+
+// bound converts a slice bound, counting negative values from the end and
+// clamping to the list.
+func (pl *PyroList) bound(value interface{}) (int, error) {
+	if !isInteger(value) {
+		return 0, NativeError{Message: "Slice bounds must be integers."}
+	}
+	size := int64(len(pl.Elements))
+	num, isSmall := value.(int64)
+	if !isSmall {
+		if sign(value) < 0 {
+			return 0, nil
+		}
+		return int(size), nil
+	}
+	if num < 0 {
+		num += size
+	}
+	if num < 0 {
+		return 0, nil
+	} else if num > size {
+		return int(size), nil
+	}
+	return int(num), nil
+}
+
+// compareValues orders two numbers or two strings for sort.
+func compareValues(a interface{}, b interface{}) (int, error) {
+	if isNumber(a) && isNumber(b) {
+		order, _ := compareNumbers(a, b)
+		return order, nil
+	}
+	switch l := a.(type) {
+	case string:
+		if r, isStr := b.(string); isStr {
+			return strings.Compare(l, r), nil
+		}
+	}
+	return 0, NativeError{Message: "Can't compare " + typeName(a) + " with " + typeName(b) + "."}
+}
+
+// toFunction checks that a value passed to a native as a callback can be
+// called, before the native has done any work.
+func toFunction(value interface{}) (Callable, error) {
+	function, isCallable := value.(Callable)
+	if !isCallable {
+		return nil, NativeError{Message: "Expected a function but got " + typeName(value) + "."}
+	}
+	return function, nil
+}
+
+// callFunction calls a script-supplied function from inside a native,
+// checking its arity the way a call expression would.
+func callFunction(interpreter *Interpreter, function Callable, arguments ...interface{}) (interface{}, error) {
+	if function.Arity() >= 0 && len(arguments) != function.Arity() {
+		return nil, NativeError{Message: "Expected " + strconv.Itoa(function.Arity()) + " arguments but got " + strconv.Itoa(len(arguments))}
+	}
+	return function.Call(interpreter, arguments)
+}
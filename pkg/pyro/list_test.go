@@ -0,0 +1,54 @@
+package pyro
+
+import "testing"
+
+func TestSortWithMutatingComparator(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"pop until empty",
+			`var xs = [3, 2, 1, 5, 4, 9, 8, 7, 6, 10, 12, 11, 13, 15, 14];
+			xs.sort(fun (a, b) { xs.pop(); return a - b; });`,
+			"error: Can't pop from an empty list.\n",
+		},
+		{
+			"pop once",
+			`var xs = [3, 1, 2];
+			var popped = false;
+			xs.sort(fun (a, b) {
+				if (!popped) { popped = true; xs.pop(); }
+				return a - b;
+			});`,
+			"error: List changed size during sort.\n",
+		},
+		{
+			"push",
+			`var xs = [3, 1, 2];
+			xs.sort(fun (a, b) { xs.push(0); return a - b; });`,
+			"error: List changed size during sort.\n",
+		},
+		{
+			"comparator error",
+			`var xs = [3, 1, 2, "x"];
+			xs.sort(fun (a, b) { if (b == "x" or a == "x") return nil; return a - b; });`,
+			"error: Sort comparator must return a number.\n",
+		},
+		{
+			"no mutation",
+			`var xs = [3, 1, 2];
+			xs.sort(fun (a, b) { return b - a; });
+			print xs;`,
+			"[3, 2, 1]\n",
+		},
+	}
+	for _, test := range tests {
+		for _, useVM := range []bool{false, true} {
+			if got := runScript(test.source, useVM); got != test.want {
+				t.Errorf("%s (vm=%v): got %q, want %q", test.name, useVM, got, test.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package pyro
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// MapEntry is one key/value pair of a PyroMap.
+type MapEntry struct {
+	Key     interface{}
+	Value   interface{}
+	removed bool
+}
+
+// PyroMap is the value of a map literal. Entries keep the order their keys
+// were first inserted in, so printing and keys() are deterministic.
+//
+// Keys are looked up with Go map equality on their mapKey, which is exactly
+// isEqual for the key types allowed: two keys find the same entry if and only
+// if == would say they are equal.
+type PyroMap struct {
+	entries []MapEntry
+	index   map[interface{}]int
+	removed int
+}
+
+func NewPyroMap() *PyroMap {
+	return &PyroMap{
+		entries: make([]MapEntry, 0),
+		index:   make(map[interface{}]int),
+	}
+}
+
+// isHashable reports whether value can be used as a map key.
+func isHashable(value interface{}) bool {
+	switch value.(type) {
+	case nil, bool, int64, *big.Int, float64, *PyroDecimal, string:
+		return true
+	}
+	return false
+}
+
+// bigKey indexes a whole number outside the int64 range by its digits.
+type bigKey string
+
+// decimalKey indexes a decimal that is neither whole nor equal to a float by
+// its digits, without trailing zeros.
+type decimalKey string
+
+// mapKey is the Go map key for a hashable value. Equal numbers share a key
+// whatever their type, so 1, 1.0 and 1.00d find the same entry.
+func mapKey(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *PyroDecimal:
+		if v.isWhole() {
+			return mapKey(v.integer())
+		}
+		if f, exact := v.Rat().Float64(); exact {
+			return f
+		}
+		return decimalKey(strings.TrimRight(v.String(), "0"))
+	case float64:
+		if v != math.Trunc(v) || math.IsInf(v, 0) {
+			return v
+		}
+		if v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v)
+		}
+		b, _ := big.NewFloat(v).Int(nil)
+		return bigKey(b.String())
+	case *big.Int:
+		return bigKey(v.String())
+	}
+	return value
+}
+
+func (pm *PyroMap) Len() int {
+	return len(pm.index)
+}
+
+func (pm *PyroMap) Get(key interface{}) (interface{}, bool) {
+	if i, exists := pm.index[mapKey(key)]; exists {
+		return pm.entries[i].Value, true
+	}
+	return nil, false
+}
+
+// Set adds or replaces the entry for key, which must be hashable.
+func (pm *PyroMap) Set(key interface{}, value interface{}) {
+	k := mapKey(key)
+	if i, exists := pm.index[k]; exists {
+		pm.entries[i].Value = value
+		return
+	}
+	pm.index[k] = len(pm.entries)
+	pm.entries = append(pm.entries, MapEntry{Key: key, Value: value})
+}
+
+// Delete removes key and reports whether it was present. Removed entries are
+// compacted away once they make up half the table.
+func (pm *PyroMap) Delete(key interface{}) bool {
+	k := mapKey(key)
+	i, exists := pm.index[k]
+	if !exists {
+		return false
+	}
+	delete(pm.index, k)
+	pm.entries[i] = MapEntry{removed: true}
+	pm.removed++
+
+	if pm.removed > len(pm.entries)/2 {
+		live := make([]MapEntry, 0, len(pm.index))
+		for _, entry := range pm.entries {
+			if !entry.removed {
+				pm.index[mapKey(entry.Key)] = len(live)
+				live = append(live, entry)
+			}
+		}
+		pm.entries = live
+		pm.removed = 0
+	}
+	return true
+}
+
+// Entries returns the live entries in insertion order. The slice is a copy,
+// so the map can be changed while ranging over it.
+func (pm *PyroMap) Entries() []MapEntry {
+	entries := make([]MapEntry, 0, len(pm.index))
+	for _, entry := range pm.entries {
+		if !entry.removed {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func (pm *PyroMap) toString() string {
+	return pm.format(make(map[interface{}]bool))
+}
+
+func (pm *PyroMap) format(seen map[interface{}]bool) string {
+	if seen[pm] {
+		return "{...}"
+	}
+	seen[pm] = true
+	defer delete(seen, pm)
+
+	parts := make([]string, 0, pm.Len())
+	for _, entry := range pm.Entries() {
+		parts = append(parts, formatElement(entry.Key, seen)+": "+formatElement(entry.Value, seen))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+var errUnhashable = NativeError{Message: "Map keys must be strings, numbers, booleans or nil."}
+
+// buildMap creates the map for a literal from its evaluated keys and values.
+// Errors are reported at brace.
+func buildMap(brace Token, keys []interface{}, values []interface{}) (*PyroMap, error) {
+	pm := NewPyroMap()
+	for i, key := range keys {
+		if !isHashable(key) {
+			return nil, NewRunTimeError(brace, errUnhashable.Message)
+		}
+		pm.Set(key, values[i])
+	}
+	return pm, nil
+}
+
+// method returns the built-in method name bound to this map.
+func (pm *PyroMap) method(name string) (*NativeFunction, bool) {
+	switch name {
+	case "has":
+		return NewNativeFunction(name, 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			_, exists := pm.Get(arguments[0])
+			return exists, nil
+		}), true
+	case "get":
+		return NewNativeFunction(name, -1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(arguments) < 1 || len(arguments) > 2 {
+				return nil, NativeError{Message: "Expected 1 or 2 arguments but got " + strconv.Itoa(len(arguments))}
+			}
+			if !isHashable(arguments[0]) {
+				return nil, errUnhashable
+			}
+			if value, exists := pm.Get(arguments[0]); exists {
+				return value, nil
+			}
+			if len(arguments) == 2 {
+				return arguments[1], nil
+			}
+			return nil, nil
+		}), true
+	case "delete":
+		return NewNativeFunction(name, 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			if !isHashable(arguments[0]) {
+				return nil, errUnhashable
+			}
+			return pm.Delete(arguments[0]), nil
+		}), true
+	case "keys":
+		return NewNativeFunction(name, 0, func(_ *Interpreter, _ []interface{}) (interface{}, error) {
+			keys := make([]interface{}, 0, pm.Len())
+			for _, entry := range pm.Entries() {
+				keys = append(keys, entry.Key)
+			}
+			return NewPyroList(keys), nil
+		}), true
+	case "values":
+		return NewNativeFunction(name, 0, func(_ *Interpreter, _ []interface{}) (interface{}, error) {
+			values := make([]interface{}, 0, pm.Len())
+			for _, entry := range pm.Entries() {
+				values = append(values, entry.Value)
+			}
+			return NewPyroList(values), nil
+		}), true
+	case "items":
+		return NewNativeFunction(name, 0, func(_ *Interpreter, _ []interface{}) (interface{}, error) {
+			items := make([]interface{}, 0, pm.Len())
+			for _, entry := range pm.Entries() {
+				items = append(items, NewPyroList([]interface{}{entry.Key, entry.Value}))
+			}
+			return NewPyroList(items), nil
+		}), true
+	case "each":
+		return NewNativeFunction(name, 1, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			function, err := toFunction(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			// Keys added by the callback are not visited; keys it deletes are
+			// skipped, and values it changes are seen.
+			for _, entry := range pm.Entries() {
+				value, exists := pm.Get(entry.Key)
+				if !exists {
+					continue
+				}
+				_, err := callFunction(interpreter, function, entry.Key, value)
+				if err != nil {
+					return nil, err
+				}
+			}
+			return nil, nil
+		}), true
+	}
+	return nil, false
+}
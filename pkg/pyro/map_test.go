@@ -0,0 +1,52 @@
+package pyro
+
+import "testing"
+
+func TestMapEachWhileChanging(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{
+			"delete a later key",
+			`var m = {"a": 1, "b": 2, "c": 3};
+			m.each(fun (k, v) { print k; if (k == "a") m.delete("b"); });`,
+			"a\nc\n",
+		},
+		{
+			"delete and re-add",
+			`var m = {"a": 1, "b": 2};
+			m.each(fun (k, v) { print k + "=" + str(v); if (k == "a") { m.delete("b"); m["b"] = 20; } });
+			print m;`,
+			"a=1\nb=20\n{\"a\": 1, \"b\": 20}\n",
+		},
+		{
+			"change a later value",
+			`var m = {"a": 1, "b": 2};
+			m.each(fun (k, v) { print v; m["b"] = 5; });`,
+			"1\n5\n",
+		},
+		{
+			"added keys wait for the next walk",
+			`var m = {"a": 1};
+			m.each(fun (k, v) { m["z" + k] = v; });
+			print m;`,
+			"{\"a\": 1, \"za\": 1}\n",
+		},
+		{
+			"delete everything",
+			`var m = {"a": 1, "b": 2, "c": 3, "d": 4};
+			m.each(fun (k, v) { print k; var keys = m.keys(); for (var i = 0; i < len(keys); i++) m.delete(keys[i]); });
+			print len(m);`,
+			"a\n0\n",
+		},
+	}
+	for _, test := range tests {
+		for _, useVM := range []bool{false, true} {
+			if got := runScript(test.source, useVM); got != test.want {
+				t.Errorf("%s (vm=%v): got %q, want %q", test.name, useVM, got, test.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package pyro
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// NativeError is returned by a native function body. The interpreter turns it
+// into a RunTimeError located at the call site.
+type NativeError struct {
+	Message string
+}
+
+func (ne NativeError) Error() string {
+	return ne.Message
+}
+
+// ExitError unwinds the interpreter when a script calls exit().
+type ExitError struct {
+	Code int
+}
+
+func (ee ExitError) Error() string {
+	return "exit " + strconv.Itoa(ee.Code)
+}
+
+// NativeFunction is a built-in implemented in Go. A negative Params accepts
+// any number of arguments.
+type NativeFunction struct {
+	Name   string
+	Params int
+	Fn     func(interpreter *Interpreter, arguments []interface{}) (interface{}, error)
+}
+
+func NewNativeFunction(name string, arity int, fn func(*Interpreter, []interface{}) (interface{}, error)) *NativeFunction {
+	return &NativeFunction{
+		Name:   name,
+		Params: arity,
+		Fn:     fn,
+	}
+}
+
+func (nf *NativeFunction) Arity() int {
+	return nf.Params
+}
+
+func (nf *NativeFunction) Call(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+	return nf.Fn(interpreter, arguments)
+}
+
+func (nf *NativeFunction) toString() string {
+	return "<native fn " + nf.Name + ">"
+}
+
+// collection is implemented by the built-in container types, whose methods
+// are looked up by name instead of being stored on the value.
+type collection interface {
+	method(name string) (*NativeFunction, bool)
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case int64, *big.Int:
+		return "int"
+	case float64:
+		return "float"
+	case *PyroDecimal:
+		return "decimal"
+	case string:
+		return "string"
+	case *PyroClass:
+		return "class"
+	case *PyroInstance:
+		return "instance"
+	case *PyroList:
+		return "list"
+	case *PyroMap:
+		return "map"
+	case Callable:
+		return "function"
+	default:
+		return "unknown"
+	}
+}
+
+// definePrelude installs the built-in functions every program can call.
+func definePrelude(globals *Environment) {
+	natives := []*NativeFunction{
+		NewNativeFunction("clock", 0, func(_ *Interpreter, _ []interface{}) (interface{}, error) {
+			return float64(time.Now().UnixNano()) / float64(time.Second), nil
+		}),
+		NewNativeFunction("input", 0, func(interpreter *Interpreter, _ []interface{}) (interface{}, error) {
+			line, err := interpreter.Stdin.ReadString('\n')
+			if err != nil && line == "" {
+				return nil, nil
+			}
+			return strings.TrimRight(line, "\r\n"), nil
+		}),
+		NewNativeFunction("len", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			switch v := arguments[0].(type) {
+			case string:
+				return int64(utf8.RuneCountInString(v)), nil
+			case *PyroList:
+				return int64(len(v.Elements)), nil
+			case *PyroMap:
+				return int64(v.Len()), nil
+			}
+			return nil, NativeError{Message: "Can't take len of " + typeName(arguments[0]) + "."}
+		}),
+		NewNativeFunction("str", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			return stringify(arguments[0]), nil
+		}),
+		NewNativeFunction("num", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			switch v := arguments[0].(type) {
+			case int64, *big.Int, float64, *PyroDecimal:
+				return v, nil
+			case string:
+				text := strings.TrimSpace(v)
+				if num, err := parseNumber(text); err == nil {
+					return num, nil
+				}
+				num, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return nil, NativeError{Message: "Can't convert '" + v + "' to a number."}
+				}
+				return num, nil
+			}
+			return nil, NativeError{Message: "Can't convert " + typeName(arguments[0]) + " to a number."}
+		}),
+		NewNativeFunction("int", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			switch v := arguments[0].(type) {
+			case int64, *big.Int:
+				return v, nil
+			case *PyroDecimal:
+				return v.integer(), nil
+			case float64:
+				num, err := truncate(v)
+				if err != nil {
+					return nil, NativeError{Message: err.Error()}
+				}
+				return num, nil
+			case string:
+				if num, err := parseNumber(strings.TrimSpace(v)); err == nil && isInteger(num) {
+					return num, nil
+				}
+				return nil, NativeError{Message: "Can't convert '" + v + "' to an integer."}
+			}
+			return nil, NativeError{Message: "Can't convert " + typeName(arguments[0]) + " to an integer."}
+		}),
+		NewNativeFunction("float", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			switch v := arguments[0].(type) {
+			case int64, *big.Int, float64, *PyroDecimal:
+				return toFloat(v), nil
+			case string:
+				num, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+				if err != nil {
+					return nil, NativeError{Message: "Can't convert '" + v + "' to a float."}
+				}
+				return num, nil
+			}
+			return nil, NativeError{Message: "Can't convert " + typeName(arguments[0]) + " to a float."}
+		}),
+		NewNativeFunction("decimal", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			switch v := arguments[0].(type) {
+			case int64, *big.Int, *PyroDecimal:
+				return toDecimal(v), nil
+			case float64:
+				num, err := floatToDecimal(v)
+				if err != nil {
+					return nil, NativeError{Message: err.Error()}
+				}
+				return num, nil
+			case string:
+				num, err := ParseDecimal(strings.TrimSuffix(strings.TrimSpace(v), "d"))
+				if err != nil {
+					return nil, NativeError{Message: "Can't convert '" + v + "' to a decimal."}
+				}
+				return num, nil
+			}
+			return nil, NativeError{Message: "Can't convert " + typeName(arguments[0]) + " to a decimal."}
+		}),
+		NewNativeFunction("round", -1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			if len(arguments) < 1 || len(arguments) > 3 {
+				return nil, NativeError{Message: "Expected 1 to 3 arguments but got " + strconv.Itoa(len(arguments))}
+			}
+			places := int64(0)
+			if len(arguments) >= 2 {
+				var isInt bool
+				if places, isInt = arguments[1].(int64); !isInt {
+					return nil, NativeError{Message: "Decimal places must be an integer."}
+				}
+				if places > maxIntegerBits || places < -maxIntegerBits {
+					return nil, NativeError{Message: "Decimal places out of range."}
+				}
+			}
+			mode := roundHalfEven
+			if len(arguments) == 3 {
+				name, isStr := arguments[2].(string)
+				if !isStr {
+					return nil, NativeError{Message: "Rounding mode must be a string."}
+				}
+				var err error
+				if mode, err = parseRoundingMode(name); err != nil {
+					return nil, NativeError{Message: err.Error()}
+				}
+			}
+			num, err := roundNumber(arguments[0], int(places), mode)
+			if err != nil {
+				return nil, NativeError{Message: err.Error()}
+			}
+			return num, nil
+		}),
+		NewNativeFunction("decimal_context", 2, func(interpreter *Interpreter, arguments []interface{}) (interface{}, error) {
+			digits, isInt := arguments[0].(int64)
+			if !isInt {
+				return nil, NativeError{Message: "Decimal digits must be an integer."}
+			}
+			mode, isStr := arguments[1].(string)
+			if !isStr {
+				return nil, NativeError{Message: "Rounding mode must be a string."}
+			}
+			context, err := newDecimalContext(digits, mode)
+			if err != nil {
+				return nil, NativeError{Message: err.Error()}
+			}
+			interpreter.decimals = context
+			return nil, nil
+		}),
+		NewNativeFunction("type", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			return typeName(arguments[0]), nil
+		}),
+		NewNativeFunction("exit", 1, func(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+			code, isInt := arguments[0].(int64)
+			if !isInt {
+				return nil, NativeError{Message: "Exit code must be an integer."}
+			}
+			return nil, ExitError{Code: int(code)}
+		}),
+	}
+
+	for _, native := range natives {
+		globals.define(native.Name, native)
+	}
+}
@@ -0,0 +1,477 @@
+package pyro
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Pyro has three kinds of number. Integers are int64 until a result no
+// longer fits, at which point they become *big.Int; every operation
+// normalizes its result, so a *big.Int always holds a value outside the int64
+// range. Floats are float64, and decimals are *PyroDecimal. Arithmetic on two
+// integers stays exact, mixing in a float makes the result a float and mixing
+// in a decimal makes it a decimal. Floats and decimals don't mix.
+
+// maxIntegerBits bounds the integers ** and << may build, so a typo such as
+// 2 ** 10 ** 10 fails with an error instead of exhausting memory.
+const maxIntegerBits = 1 << 24
+
+var errIntegerTooLarge = errors.New("Integer result is too large")
+
+func isInteger(value interface{}) bool {
+	switch value.(type) {
+	case int64, *big.Int:
+		return true
+	}
+	return false
+}
+
+func isNumber(value interface{}) bool {
+	switch value.(type) {
+	case int64, *big.Int, float64, *PyroDecimal:
+		return true
+	}
+	return false
+}
+
+// normalize returns b as an int64 when it fits.
+func normalize(b *big.Int) interface{} {
+	if b.IsInt64() {
+		return b.Int64()
+	}
+	return b
+}
+
+// toBig returns an integer as a *big.Int. The result may be the value
+// itself, so callers must not modify it.
+func toBig(value interface{}) *big.Int {
+	if b, isBig := value.(*big.Int); isBig {
+		return b
+	}
+	return big.NewInt(value.(int64))
+}
+
+// toFloat converts a number to the nearest float64.
+func toFloat(value interface{}) float64 {
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	case *PyroDecimal:
+		f, _ := v.Rat().Float64()
+		return f
+	}
+	return value.(float64)
+}
+
+// sign returns -1, 0 or 1 for an integer.
+func sign(value interface{}) int {
+	if b, isBig := value.(*big.Int); isBig {
+		return b.Sign()
+	}
+	switch v := value.(int64); {
+	case v < 0:
+		return -1
+	case v > 0:
+		return 1
+	}
+	return 0
+}
+
+// parseNumber converts the text of a number literal: an integer, possibly
+// with a 0x, 0b or 0o prefix, a decimal float, or a decimal with a d suffix.
+// Underscores between digits are ignored.
+func parseNumber(text string) (interface{}, error) {
+	digits := strings.ReplaceAll(text, "_", "")
+	base := 10
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X':
+			base = 16
+		case 'b', 'B':
+			base = 2
+		case 'o', 'O':
+			base = 8
+		}
+	}
+	if base != 10 {
+		digits = digits[2:]
+	} else if strings.HasSuffix(digits, "d") {
+		return ParseDecimal(digits[:len(digits)-1])
+	} else if strings.Contains(digits, ".") {
+		return strconv.ParseFloat(digits, 64)
+	}
+
+	if n, err := strconv.ParseInt(digits, base, 64); err == nil {
+		return n, nil
+	}
+	if n, ok := new(big.Int).SetString(digits, base); ok {
+		return n, nil
+	}
+	return nil, errors.New("invalid number " + strconv.Quote(text))
+}
+
+// formatFloat prints a float so that it reads back as the same float. Whole
+// floats keep a ".0" so they can be told apart from integers.
+func formatFloat(f float64) string {
+	if f == math.Trunc(f) && math.Abs(f) < 1e21 {
+		return strconv.FormatFloat(f, 'f', -1, 64) + ".0"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// compareNumbers orders two numbers exactly, even a large integer against a
+// float or a decimal against a float. It reports false if either is NaN,
+// which is unordered.
+func compareNumbers(left interface{}, right interface{}) (int, bool) {
+	if l, isSmall := left.(int64); isSmall {
+		if r, isSmall := right.(int64); isSmall {
+			switch {
+			case l < r:
+				return -1, true
+			case l > r:
+				return 1, true
+			}
+			return 0, true
+		}
+	}
+	if isDecimal(left) || isDecimal(right) {
+		l, lIsFinite := exactRat(left)
+		r, rIsFinite := exactRat(right)
+		if lIsFinite && rIsFinite {
+			return l.Cmp(r), true
+		}
+	}
+
+	l, lIsOrdered := exactFloat(left)
+	r, rIsOrdered := exactFloat(right)
+	if !lIsOrdered || !rIsOrdered {
+		return 0, false
+	}
+	return l.Cmp(r), true
+}
+
+// exactFloat converts a number to a big.Float without rounding, except for
+// a decimal, which compareNumbers only converts when comparing it with an
+// infinity. NaN has no big.Float, so it reports false.
+func exactFloat(value interface{}) (*big.Float, bool) {
+	switch v := value.(type) {
+	case int64:
+		return new(big.Float).SetInt64(v), true
+	case *big.Int:
+		return new(big.Float).SetInt(v), true
+	case *PyroDecimal:
+		return new(big.Float).SetRat(v.Rat()), true
+	}
+	f := value.(float64)
+	if math.IsNaN(f) {
+		return nil, false
+	}
+	return new(big.Float).SetFloat64(f), true
+}
+
+// exactRat converts a number to a big.Rat. Infinities and NaN have none, so
+// it reports false for them.
+func exactRat(value interface{}) (*big.Rat, bool) {
+	switch v := value.(type) {
+	case int64:
+		return new(big.Rat).SetInt64(v), true
+	case *big.Int:
+		return new(big.Rat).SetInt(v), true
+	case *PyroDecimal:
+		return v.Rat(), true
+	}
+	f := value.(float64)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetFloat64(f), true
+}
+
+// compare applies <, <=, > or >= to two numbers. Every comparison with NaN
+// is false.
+func compare(operator Token, left interface{}, right interface{}) (interface{}, error) {
+	if !isNumber(left) || !isNumber(right) {
+		return nil, NewRunTimeError(operator, "Operands must be a number")
+	}
+	order, ordered := compareNumbers(left, right)
+	if !ordered {
+		return false, nil
+	}
+
+	switch operator.Type {
+	case GT:
+		return order > 0, nil
+	case GE:
+		return order >= 0, nil
+	case LT:
+		return order < 0, nil
+	}
+	return order <= 0, nil
+}
+
+// arithmetic applies +, -, *, /, //, % or ** to two numbers. Integers give
+// integers, growing into *big.Int rather than overflowing, except that /
+// always gives a float and so does ** with a negative exponent. If either
+// operand is a float the result is a float, and if either is a decimal the
+// result is a decimal, rounded as decimals says where it must be.
+func arithmetic(operator Token, left interface{}, right interface{}, decimals decimalContext) (interface{}, error) {
+	if !isNumber(left) || !isNumber(right) {
+		return nil, NewRunTimeError(operator, "Operands must be a number")
+	}
+	if isDecimal(left) || isDecimal(right) {
+		_, lIsFloat := left.(float64)
+		_, rIsFloat := right.(float64)
+		if lIsFloat || rIsFloat {
+			return nil, NewRunTimeError(operator, errMixedDecimal.Error())
+		}
+		return decimalArithmetic(operator, left, right, decimals)
+	}
+	if !isInteger(left) || !isInteger(right) {
+		return floatArithmetic(operator.Type, toFloat(left), toFloat(right)), nil
+	}
+
+	switch operator.Type {
+	case SLASH:
+		return divide(left, right), nil
+	case SLASHSLASH, MOD:
+		if sign(right) == 0 {
+			return nil, NewRunTimeError(operator, "Division by zero")
+		}
+	case STARSTAR:
+		if sign(right) < 0 {
+			return math.Pow(toFloat(left), toFloat(right)), nil
+		}
+	}
+
+	l, lIsSmall := left.(int64)
+	r, rIsSmall := right.(int64)
+	if lIsSmall && rIsSmall {
+		if result, fits := smallArithmetic(operator.Type, l, r); fits {
+			return result, nil
+		}
+	}
+	result, err := bigArithmetic(operator.Type, toBig(left), toBig(right))
+	if err != nil {
+		return nil, NewRunTimeError(operator, err.Error())
+	}
+	return result, nil
+}
+
+func floatArithmetic(operator TokenType, l float64, r float64) float64 {
+	switch operator {
+	case PLUS:
+		return l + r
+	case MINUS:
+		return l - r
+	case STAR:
+		return l * r
+	case SLASH:
+		return l / r
+	case MOD:
+		return floatMod(l, r)
+	case SLASHSLASH:
+		return math.Floor(l / r)
+	}
+	return math.Pow(l, r)
+}
+
+// floorMod is % on integers: the remainder of floor division, so a nonzero
+// result takes the sign of r and l == (l // r) * r + l % r.
+func floorMod(l int64, r int64) int64 {
+	if uint64(l) <= math.MaxUint32 && uint64(r) <= math.MaxUint32 {
+		// Both are non-negative and fit in 32 bits, where division is cheaper.
+		return int64(uint32(l) % uint32(r))
+	}
+	m := l % r
+	if m != 0 && (m < 0) != (r < 0) {
+		m += r
+	}
+	return m
+}
+
+// smallArithmetic is the int64 fast path of arithmetic. It reports false
+// when the result does not fit, and leaves ** to bigArithmetic. The divisor
+// of // and % is never zero.
+func smallArithmetic(operator TokenType, l int64, r int64) (int64, bool) {
+	switch operator {
+	case PLUS:
+		sum := l + r
+		return sum, (sum >= l) == (r >= 0)
+	case MINUS:
+		difference := l - r
+		return difference, (difference <= l) == (r >= 0)
+	case STAR:
+		if l == 0 || r == 0 {
+			return 0, true
+		}
+		product := l * r
+		overflows := product/r != l || (l == -1 && r == math.MinInt64) || (r == -1 && l == math.MinInt64)
+		return product, !overflows
+	case SLASHSLASH:
+		if l == math.MinInt64 && r == -1 {
+			return 0, false
+		}
+		quotient := l / r
+		if l%r != 0 && (l < 0) != (r < 0) {
+			quotient--
+		}
+		return quotient, true
+	case MOD:
+		return floorMod(l, r), true
+	}
+	return 0, false
+}
+
+func bigArithmetic(operator TokenType, l *big.Int, r *big.Int) (interface{}, error) {
+	result := new(big.Int)
+	switch operator {
+	case PLUS:
+		result.Add(l, r)
+	case MINUS:
+		result.Sub(l, r)
+	case STAR:
+		result.Mul(l, r)
+	case SLASHSLASH:
+		remainder := new(big.Int)
+		result.QuoRem(l, r, remainder)
+		if remainder.Sign() != 0 && (l.Sign() < 0) != (r.Sign() < 0) {
+			result.Sub(result, big.NewInt(1))
+		}
+	case MOD:
+		result.Rem(l, r)
+		if result.Sign() != 0 && (result.Sign() < 0) != (r.Sign() < 0) {
+			result.Add(result, r)
+		}
+	case STARSTAR:
+		if l.CmpAbs(big.NewInt(1)) > 0 && (!r.IsInt64() || r.Int64() > maxIntegerBits || int64(l.BitLen())*r.Int64() > maxIntegerBits) {
+			return nil, errIntegerTooLarge
+		}
+		result.Exp(l, r, nil)
+	}
+	return normalize(result), nil
+}
+
+// divide is / on two integers, rounded once to the nearest float.
+func divide(left interface{}, right interface{}) float64 {
+	const exact = 1 << 53
+	l, lIsSmall := left.(int64)
+	r, rIsSmall := right.(int64)
+	if sign(right) == 0 || lIsSmall && rIsSmall && -exact <= l && l <= exact && -exact <= r && r <= exact {
+		return toFloat(left) / toFloat(right)
+	}
+	f, _ := new(big.Rat).SetFrac(toBig(left), toBig(right)).Float64()
+	return f
+}
+
+// bitwise applies &, |, ^, << or >> to two integers, which behave as two's
+// complement numbers with as many bits as they need.
+func bitwise(operator Token, left interface{}, right interface{}) (interface{}, error) {
+	if !isInteger(left) || !isInteger(right) {
+		return nil, NewRunTimeError(operator, "Operands must be integers")
+	}
+	if operator.Type == LTLT || operator.Type == GTGT {
+		return shift(operator, left, right)
+	}
+
+	l, lIsSmall := left.(int64)
+	r, rIsSmall := right.(int64)
+	if lIsSmall && rIsSmall {
+		switch operator.Type {
+		case AMP:
+			return l & r, nil
+		case PIPE:
+			return l | r, nil
+		}
+		return l ^ r, nil
+	}
+
+	result := new(big.Int)
+	switch operator.Type {
+	case AMP:
+		result.And(toBig(left), toBig(right))
+	case PIPE:
+		result.Or(toBig(left), toBig(right))
+	default:
+		result.Xor(toBig(left), toBig(right))
+	}
+	return normalize(result), nil
+}
+
+func shift(operator Token, left interface{}, right interface{}) (interface{}, error) {
+	if sign(right) < 0 {
+		return nil, NewRunTimeError(operator, "Shift count must not be negative")
+	}
+	count, countIsSmall := right.(int64)
+	l, lIsSmall := left.(int64)
+
+	if operator.Type == GTGT {
+		if !countIsSmall {
+			if sign(left) < 0 {
+				return int64(-1), nil
+			}
+			return int64(0), nil
+		}
+		if lIsSmall {
+			return l >> uint64(count), nil
+		}
+		return normalize(new(big.Int).Rsh(toBig(left), uint(count))), nil
+	}
+
+	if sign(left) == 0 {
+		return int64(0), nil
+	}
+	if !countIsSmall || count > maxIntegerBits {
+		return nil, NewRunTimeError(operator, errIntegerTooLarge.Error())
+	}
+	if lIsSmall && count < 63 && (l<<count)>>count == l {
+		return l << count, nil
+	}
+	return normalize(new(big.Int).Lsh(toBig(left), uint(count))), nil
+}
+
+// negate is unary minus.
+func negate(operator Token, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		if v != math.MinInt64 {
+			return -v, nil
+		}
+		return new(big.Int).Neg(big.NewInt(v)), nil
+	case *big.Int:
+		return normalize(new(big.Int).Neg(v)), nil
+	case float64:
+		return -v, nil
+	case *PyroDecimal:
+		return newDecimal(new(big.Int).Neg(v.unscaled), v.scale), nil
+	}
+	return nil, NewRunTimeError(operator, "Operand must be a number")
+}
+
+// complement is unary ~.
+func complement(operator Token, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int64:
+		return ^v, nil
+	case *big.Int:
+		return normalize(new(big.Int).Not(v)), nil
+	}
+	return nil, NewRunTimeError(operator, "Operand must be an integer")
+}
+
+// truncate converts a float to the integer with the same whole part.
+func truncate(f float64) (interface{}, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, errors.New("Can't convert " + formatFloat(f) + " to an integer.")
+	}
+	if f >= math.MinInt64 && f < math.MaxInt64 {
+		return int64(f), nil
+	}
+	b, _ := big.NewFloat(f).Int(nil)
+	return normalize(b), nil
+}
@@ -0,0 +1,53 @@
+package pyro
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// TestFloorMod checks % and // on integers and floats against floored
+// division done with math/big, including the fast path for small
+// non-negative operands and the edges of int64.
+func TestFloorMod(t *testing.T) {
+	values := []int64{0, 1, 2, 3, 7, 15, 1 << 31, 1<<32 - 1, 1 << 32, math.MaxInt64, math.MinInt64}
+	for _, v := range append([]int64(nil), values...) {
+		if v != math.MinInt64 {
+			values = append(values, -v)
+		}
+	}
+
+	for _, l := range values {
+		for _, r := range values {
+			if r == 0 {
+				continue
+			}
+			want := new(big.Int)
+			quo, _ := new(big.Int).DivMod(big.NewInt(l), big.NewInt(r), want)
+			// DivMod is Euclidean; floored division differs when r < 0.
+			if r < 0 && want.Sign() != 0 {
+				want.Add(want, big.NewInt(r))
+				quo.Sub(quo, big.NewInt(1))
+			}
+			if got := floorMod(l, r); got != want.Int64() {
+				t.Errorf("floorMod(%d, %d) = %d, want %s", l, r, got, want)
+			}
+			if got, _ := bigArithmetic(MOD, big.NewInt(l), big.NewInt(r)); toBig(got).Cmp(want) != 0 {
+				t.Errorf("big %d %% %d = %v, want %s", l, r, got, want)
+			}
+			if got, _ := bigArithmetic(SLASHSLASH, big.NewInt(l), big.NewInt(r)); toBig(got).Cmp(quo) != 0 {
+				t.Errorf("big %d // %d = %v, want %s", l, r, got, quo)
+			}
+		}
+	}
+
+	for _, test := range []struct{ l, r, want float64 }{
+		{7.5, 2, 1.5}, {-7.5, 2, 0.5}, {7.5, -2, -0.5}, {-7.5, -2, -1.5},
+		{6, 3, 0}, {-6, 3, 0}, {6, -3, math.Copysign(0, -1)}, {1, math.Inf(1), 1}, {-1, math.Inf(1), math.Inf(1)},
+	} {
+		got := floatMod(test.l, test.r)
+		if got != test.want || math.Signbit(got) != math.Signbit(test.want) {
+			t.Errorf("floatMod(%v, %v) = %v, want %v", test.l, test.r, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,1007 @@
+package pyro
+
+// TokenSource supplies tokens one at a time. After the last token it must
+// keep returning EOF.
+type TokenSource interface {
+	NextToken() Token
+}
+
+// Parser builds statements from a token stream. It only holds the tokens it
+// is looking ahead at plus the one it consumed last, so memory use does not
+// grow with the length of the input.
+type Parser struct {
+	Tokens      TokenSource
+	Diagnostics *Diagnostics
+
+	lookahead []Token
+	last      Token
+}
+
+func NewParser(tokens TokenSource, diagnostics *Diagnostics) *Parser {
+	return &Parser{
+		Tokens:      tokens,
+		Diagnostics: diagnostics,
+		lookahead:   make([]Token, 0, 2),
+	}
+}
+
+func (p *Parser) parse() ([]Stmt, error) {
+	statements := make([]Stmt, 0)
+
+	for !p.isAtEnd() {
+		declar, err := p.declaration()
+		if err != nil {
+			if _, isParse := err.(ParseError); !isParse {
+				return statements, err
+			}
+		} else {
+			statements = append(statements, declar)
+		}
+	}
+
+	return statements, nil
+}
+
+func (p *Parser) declaration() (Stmt, error) {
+	if p.match(CLASS) {
+		class, err := p.classDeclaration()
+		if _, isParse := err.(ParseError); isParse {
+			p.synchronize()
+		}
+		return class, err
+	}
+	if p.match(VAR) {
+		declar, err := p.varDeclaration()
+
+		if _, isParse := err.(ParseError); isParse {
+			p.synchronize()
+			return declar, nil
+		}
+		return declar, err
+	}
+	stmt, err := p.statement()
+	if _, isParse := err.(ParseError); isParse {
+		p.synchronize()
+	}
+	return stmt, err
+}
+
+func (p *Parser) classDeclaration() (Stmt, error) {
+	keyword := p.previous()
+	name, err := p.consume(ID, "Expect class name")
+	if err != nil {
+		return nil, err
+	}
+
+	var superclass *Variable
+	if p.match(LT) {
+		_, err = p.consume(ID, "Expect superclass name")
+		if err != nil {
+			return nil, err
+		}
+		temp := NewVariable(p.previous())
+		superclass = &temp
+	}
+
+	_, err = p.consume(LBRACE, "Expect '{' before class body")
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]Function, 0)
+	for !p.check(RBRACE) && !p.isAtEnd() {
+		method, err := p.function("method")
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method.(Function))
+	}
+
+	_, err = p.consume(RBRACE, "Expect '}' after class body")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewClass(name, superclass, methods, p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) varDeclaration() (Var, error) {
+	keyword := p.previous()
+	name, err := p.consume(ID, "Expect variable name")
+	if err != nil {
+		return Var{}, err
+	}
+
+	var initalizer *Expr
+
+	if p.match(EQ) {
+		temp, err := p.expression()
+		initalizer = &temp
+		if err != nil {
+			return Var{}, err
+		}
+	}
+	_, err = p.consume(SEMICOLON, "Expect ';' after value")
+	if err != nil {
+		return Var{}, err
+	}
+
+	return NewVar(name, initalizer, p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) statement() (Stmt, error) {
+	if p.match(PRINT) {
+		printStmt, err := p.printStatement()
+		if err != nil {
+			return nil, err
+		}
+		return printStmt, nil
+	} else if !p.atMapLiteral() && p.match(LBRACE) {
+		brace := p.previous()
+		block, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		return NewBlock(block, p.spanFrom(brace)), nil
+	} else if p.match(IF) {
+		ifStmt, err := p.ifStatement()
+		return ifStmt, err
+	} else if p.match(WHILE) {
+		whileStmt, err := p.whileStatement()
+		return whileStmt, err
+	} else if p.match(RETURN) {
+		returnStmt, err := p.returnStatement()
+		return returnStmt, err
+	} else if p.match(BREAK, CONTINUE) {
+		return p.jumpStatement()
+	} else if p.match(FOR) {
+		keyword := p.previous()
+		_, err := p.consume(LPAREN, "Expect '(' after for")
+		if err != nil {
+			return nil, err
+		}
+
+		var intializer Stmt
+		if p.match(SEMICOLON) {
+			intializer = nil
+		} else if p.match(VAR) {
+			intializer, err = p.varDeclaration()
+		} else {
+			intializer, err = p.expressionStatement()
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var condition *Expr
+		if !p.check(SEMICOLON) {
+			temp, err := p.expression()
+			condition = &temp
+			if err != nil {
+				return nil, err
+			}
+		}
+		_, err = p.consume(SEMICOLON, "Expect ';' after loop condition")
+		if err != nil {
+			return nil, err
+		}
+
+		var increment *Expr
+		if !p.check(RPAREN) {
+			temp, err := p.expression()
+			increment = &temp
+			if err != nil {
+				return nil, err
+			}
+		}
+		_, err = p.consume(RPAREN, "Expect ')' after for clauses")
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+
+		span := p.spanFrom(keyword)
+		if condition == nil {
+			body = NewWhile(NewLiteral(true, keyword.Span), body, increment, span)
+		} else {
+			body = NewWhile(*condition, body, increment, span)
+		}
+
+		if intializer != nil {
+			statements := []Stmt{intializer, body}
+			body = NewBlock(statements, span)
+		}
+		return body, nil
+
+	} else if p.check(FUN) && p.peekAt(1).Type != LPAREN {
+		p.advance()
+		function, err := p.function("function")
+		if err != nil {
+			return nil, err
+		}
+		return function, nil
+	}
+
+	expressionStmt, err := p.expressionStatement()
+	return expressionStmt, err
+}
+
+func (p *Parser) function(kind string) (Stmt, error) {
+	name, err := p.consume(ID, "Expect " + kind + " name")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LPAREN, "Expect '(' after " + kind + "name")
+	if err != nil {
+		return nil, err
+	}
+
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(LBRACE, "Expect '{' before " + kind + " body")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err  := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	function := NewFunction(name, parameters, body, p.spanFrom(name))
+	return function, err 
+}
+
+// parameters parses a parameter list after its opening '(', up to and
+// including the closing ')'.
+func (p *Parser) parameters() ([]Token, error) {
+	parameters := make([]Token, 0)
+
+	if !p.check(RPAREN) {
+		for {
+			if len(parameters) >= 255 {
+				p.error(p.peek(), "Can't have more than 255 parameters")
+			}
+			parameter, err := p.consume(ID, "Expected parameter name")
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, parameter)
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+	_, err := p.consume(RPAREN, "Expected ')' after paramters")
+	if err != nil {
+		return nil, err
+	}
+	return parameters, nil
+}
+
+func (p *Parser) returnStatement() (Stmt, error) {
+	keyword := p.previous()
+
+	var value *Expr
+	if !p.check(SEMICOLON) {
+		temp, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		value = &temp
+	}
+
+	_, err := p.consume(SEMICOLON, "Expect ';' after return value")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewReturn(keyword, value, p.spanFrom(keyword)), nil
+}
+
+// jumpStatement parses break or continue. The resolver checks that it sits
+// inside a loop.
+func (p *Parser) jumpStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(SEMICOLON, "Expect ';' after '"+keyword.Lexeme+"'")
+	if err != nil {
+		return nil, err
+	}
+
+	if keyword.Type == BREAK {
+		return NewBreak(keyword, p.spanFrom(keyword)), nil
+	}
+	return NewContinue(keyword, p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) whileStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(LPAREN, "Expected '(' after while")
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(RPAREN, "Expected ')' after while condition")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWhile(condition, body, nil, p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) ifStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(LPAREN, "Expected '(' after 'if'")
+	if err != nil {
+		return nil, err
+	}
+
+	condition, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RPAREN, "Expected ')' after 'if' condition")
+	if err != nil {
+		return nil, err
+	}
+
+	thenBranch, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	var elseBranch *Stmt
+	if p.match(ELSE) {
+		temp, err := p.statement()
+		elseBranch = &temp
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return NewIf(condition, thenBranch, elseBranch, p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) block() ([]Stmt, error) {
+	var statements []Stmt
+
+	for !p.check(RBRACE) && !p.isAtEnd() {
+		stmt, err := p.declaration()
+		if err != nil {
+			return nil, err
+		}
+		statements = append(statements, stmt)
+	}
+	_, err := p.consume(RBRACE, "Expect '}' after block")
+	if err != nil {
+		return nil, err
+	}
+
+	return statements, nil
+
+}
+
+func (p *Parser) printStatement() (Print, error) {
+	keyword := p.previous()
+	value, err := p.expression()
+	if err != nil {
+		return Print{}, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expect ';' after value")
+	if err != nil {
+		return Print{}, err
+	}
+	return NewPrint(value, p.spanFrom(keyword)), nil
+}
+
+func (p *Parser) expressionStatement() (Expression, error) {
+	expr, err := p.expression()
+	if err != nil {
+		return Expression{}, err
+	}
+
+	_, err = p.consume(SEMICOLON, "Expect ';' after value")
+	if err != nil {
+		return Expression{}, err
+	}
+
+	return NewExpression(expr, expr.Span().To(p.previous().Span)), nil
+}
+
+func (p *Parser) expression() (Expr, error) {
+	return p.assignment()
+}
+
+func (p *Parser) assignment() (Expr, error) {
+	expr, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(EQ) {
+		equals := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+
+		if v, isVariable := expr.(Variable); isVariable {
+			name := v.Name
+			return NewAssign(name, value), nil
+		} else if get, isGet := expr.(Get); isGet {
+			return NewSet(get.Object, get.Name, value), nil
+		} else if subscript, isSubscript := expr.(Subscript); isSubscript {
+			return NewSubscriptSet(subscript.Object, subscript.Bracket, subscript.Index, value), nil
+		}
+
+		return nil, p.errorSpan(equals, expr.Span(), "Invalid assignment target.")
+	}
+
+	if p.match(PLUSEQ, MINUSEQ, STAREQ, SLASHEQ, MODEQ) {
+		operator := p.previous()
+		value, err := p.assignment()
+		if err != nil {
+			return nil, err
+		}
+		return p.compoundAssign(expr, operator, value, false)
+	}
+
+	return expr, nil
+}
+
+// compoundAssign builds target op= value once target is known to be
+// something that can be assigned to.
+func (p *Parser) compoundAssign(target Expr, operator Token, value Expr, postfix bool) (Expr, error) {
+	switch target.(type) {
+	case Variable, Get, Subscript:
+		return NewCompoundAssign(target, operator, value, postfix), nil
+	}
+	return nil, p.errorSpan(operator, target.Span(), "Invalid assignment target.")
+}
+
+// increment builds ++target or target++ (or --), which add one to target
+// the way target += 1 would.
+func (p *Parser) increment(target Expr, operator Token, postfix bool) (Expr, error) {
+	return p.compoundAssign(target, operator, NewLiteral(int64(1), operator.Span), postfix)
+}
+
+// conditional parses cond ? then : else. The else branch may itself be a
+// conditional, so chains group to the right.
+func (p *Parser) conditional() (Expr, error) {
+	expr, err := p.binary(precCoalesce)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(QUESTION) {
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(COLON, "Expect ':' after then branch of conditional expression")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+		return NewConditional(expr, thenBranch, elseBranch), nil
+	}
+	return expr, nil
+}
+
+// Binary operator precedences, from loosest to tightest. Prefix operators
+// bind tighter than all of them except '**', so -2 ** 2 is -(2 ** 2).
+const (
+	precCoalesce = iota + 1
+	precOr
+	precAnd
+	precEquality
+	precComparison
+	precBitOr
+	precBitXor
+	precBitAnd
+	precShift
+	precTerm
+	precFactor
+	precPower
+)
+
+// binaryPrecedence drives the precedence climber in binary. Every operator
+// associates to the left except '**'.
+var binaryPrecedence = map[TokenType]int{
+	QUESTIONQUESTION: precCoalesce,
+	OR:               precOr,
+	AND:              precAnd,
+	EQEQ:             precEquality,
+	NE:               precEquality,
+	LT:               precComparison,
+	LE:               precComparison,
+	GT:               precComparison,
+	GE:               precComparison,
+	PIPE:             precBitOr,
+	CARET:            precBitXor,
+	AMP:              precBitAnd,
+	LTLT:             precShift,
+	GTGT:             precShift,
+	PLUS:             precTerm,
+	MINUS:            precTerm,
+	STAR:             precFactor,
+	SLASH:            precFactor,
+	SLASHSLASH:       precFactor,
+	MOD:              precFactor,
+	STARSTAR:         precPower,
+}
+
+// binary parses a chain of infix operators that bind at least as tightly as
+// minPrecedence. 'and', 'or' and '??' get their own nodes so they
+// short-circuit.
+func (p *Parser) binary(minPrecedence int) (Expr, error) {
+	expr, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		precedence, isBinary := binaryPrecedence[p.peek().Type]
+		if !isBinary || precedence < minPrecedence {
+			return expr, nil
+		}
+		operator := p.advance()
+
+		next := precedence + 1
+		if operator.Type == STARSTAR {
+			next = precedence
+		}
+		right, err := p.binary(next)
+		if err != nil {
+			return nil, err
+		}
+
+		switch operator.Type {
+		case AND, OR:
+			expr = NewLogical(expr, operator, right)
+		case QUESTIONQUESTION:
+			expr = NewCoalesce(expr, operator, right)
+		default:
+			expr = NewBinary(expr, operator, right)
+		}
+	}
+}
+
+func (p *Parser) unary() (Expr, error) {
+	if p.match(NOT, MINUS, TILDE) {
+		operator := p.previous()
+		right, err := p.binary(precPower)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewUnary(operator, right), nil
+	}
+	if p.match(PLUSPLUS, MINUSMINUS) {
+		operator := p.previous()
+		target, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return p.increment(target, operator, false)
+	}
+	return p.postfix()
+}
+
+func (p *Parser) postfix() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.match(PLUSPLUS, MINUSMINUS) {
+		return p.increment(expr, p.previous(), true)
+	}
+	return expr, nil
+}
+
+func (p *Parser) call() (Expr, error) {
+	expr, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if p.match(LPAREN) {
+			expr, err = p.finishCall(expr)
+			if err != nil {
+				return nil, err
+			}
+		} else if p.match(DOT) {
+			name, err := p.consume(ID, "Expect property name after '.'")
+			if err != nil {
+				return nil, err
+			}
+			expr = NewGet(expr, name)
+		} else if p.match(LBRACKET) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			bracket, err := p.consume(RBRACKET, "Expect ']' after index")
+			if err != nil {
+				return nil, err
+			}
+			expr = NewSubscript(expr, bracket, index)
+		} else {
+			break
+		}
+	}
+	return expr, nil
+}
+
+func (p *Parser) finishCall(callee Expr) (Expr, error) {
+	arguments := make([]Expr, 0)
+
+	if !p.check(RPAREN) {
+		for {
+			if len(arguments) >= 255 {
+				p.error(p.peek(), "Can't have more than 255 arguments")
+			}
+
+			expr, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			arguments = append(arguments, expr)
+
+			if !p.match(COMMA) {
+				break
+			}
+		}
+	}
+
+	paren, err := p.consume(RPAREN, "Expected ')' after arguments")
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCall(callee, paren, arguments), nil
+}
+
+func (p *Parser) primary() (Expr, error) {
+	if p.match(NIL) {
+		return NewLiteral(nil, p.previous().Span), nil
+	} else if p.match(TRUE) {
+		return NewLiteral(true, p.previous().Span), nil
+	} else if p.match(FALSE) {
+		return NewLiteral(false, p.previous().Span), nil
+	} else if p.match(NUM) {
+		num, err := parseNumber(p.previous().Lexeme)
+		if err != nil {
+			return nil, p.error(p.previous(), "Number literal is out of range.")
+		}
+		return NewLiteral(num, p.previous().Span), nil
+	} else if p.match(STRING) {
+		return NewLiteral(p.previous().Lexeme, p.previous().Span), nil
+	} else if p.match(INTERPOLATION) {
+		return p.interpolation()
+	} else if p.match(FUN) {
+		return p.lambda()
+	} else if p.match(LPAREN) {
+		return p.parenthesized()
+	} else if p.match(LBRACKET) {
+		return p.listLiteral()
+	} else if p.match(LBRACE) {
+		return p.mapLiteral()
+	} else if p.match(SUPER) {
+		keyword := p.previous()
+		_, err := p.consume(DOT, "Expect '.' after 'super'")
+		if err != nil {
+			return nil, err
+		}
+		method, err := p.consume(ID, "Expect superclass method name")
+		if err != nil {
+			return nil, err
+		}
+		return NewSuper(keyword, method), nil
+	} else if p.match(THIS) {
+		return NewThis(p.previous()), nil
+	} else if p.match(ID) {
+		return NewVariable(p.previous()), nil
+	}
+	return nil, p.error(p.peek(), "Expected expression")
+}
+
+// interpolation parses a string with embedded ${...} expressions. The
+// scanner hands it over as an INTERPOLATION token before each expression,
+// the '}' after it, and a STRING token after the last one.
+func (p *Parser) interpolation() (Expr, error) {
+	start := p.previous()
+	parts := make([]Expr, 0)
+	for {
+		segment := p.previous()
+		if segment.Lexeme != "" {
+			parts = append(parts, NewLiteral(segment.Lexeme, segment.Span))
+		}
+		if segment.Type == STRING {
+			break
+		}
+
+		expr, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, expr)
+
+		_, err = p.consume(RBRACE, "Expect '}' after interpolated expression")
+		if err != nil {
+			return nil, err
+		}
+		if !p.match(INTERPOLATION, STRING) {
+			return nil, p.error(p.peek(), "Expect rest of string after interpolation")
+		}
+	}
+	return NewInterpolation(parts, p.spanFrom(start)), nil
+}
+
+// lambda parses an anonymous function after its 'fun' keyword.
+func (p *Parser) lambda() (Expr, error) {
+	keyword := p.previous()
+	_, err := p.consume(LPAREN, "Expect '(' after 'fun'")
+	if err != nil {
+		return nil, err
+	}
+	parameters, err := p.parameters()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LBRACE, "Expect '{' before function body")
+	if err != nil {
+		return nil, err
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+
+	name := NewToken(ID, "lambda", keyword.Span)
+	return NewLambda(NewFunction(name, parameters, body, p.spanFrom(keyword))), nil
+}
+
+// parenthesized parses what follows a '(': a grouping, or the parameter
+// list of an arrow function. Both can start with a name, so the first
+// expression is parsed before deciding, and it becomes the first parameter
+// if a ',' or ") =>" follows. That keeps the lookahead to two tokens however
+// long the parameter list is.
+func (p *Parser) parenthesized() (Expr, error) {
+	paren := p.previous()
+	if p.check(RPAREN) && p.peekAt(1).Type == ARROW {
+		p.advance()
+		return p.arrowFunction(paren, []Token{})
+	}
+
+	expr, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	if !p.check(COMMA) && !(p.check(RPAREN) && p.peekAt(1).Type == ARROW) {
+		_, err = p.consume(RPAREN, "Expected ')' after expression")
+		if err != nil {
+			return nil, err
+		}
+		return expr, nil //changed grouping
+	}
+
+	first, isVariable := expr.(Variable)
+	if !isVariable {
+		return nil, p.errorSpan(p.peek(), expr.Span(), "Expected parameter name")
+	}
+	parameters := []Token{first.Name}
+	for p.match(COMMA) {
+		if len(parameters) >= 255 {
+			p.error(p.peek(), "Can't have more than 255 parameters")
+		}
+		parameter, err := p.consume(ID, "Expected parameter name")
+		if err != nil {
+			return nil, err
+		}
+		parameters = append(parameters, parameter)
+	}
+	_, err = p.consume(RPAREN, "Expected ')' after paramters")
+	if err != nil {
+		return nil, err
+	}
+	return p.arrowFunction(paren, parameters)
+}
+
+// arrowFunction parses the "=> expr" of an arrow function whose parameters
+// have been read, into a lambda that returns expr.
+func (p *Parser) arrowFunction(paren Token, parameters []Token) (Expr, error) {
+	arrow, err := p.consume(ARROW, "Expect '=>' after parameters")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	name := NewToken(ID, "lambda", paren.Span)
+	body := []Stmt{NewReturn(arrow, &value, arrow.Span.To(value.Span()))}
+	return NewLambda(NewFunction(name, parameters, body, p.spanFrom(paren))), nil
+}
+
+// listLiteral parses the elements of a list literal after its opening '['. A
+// trailing comma is allowed so long literals can be written one per line.
+func (p *Parser) listLiteral() (Expr, error) {
+	bracket := p.previous()
+	elements := make([]Expr, 0)
+
+	for !p.check(RBRACKET) {
+		element, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	_, err := p.consume(RBRACKET, "Expect ']' after list elements")
+	if err != nil {
+		return nil, err
+	}
+	return NewList(elements, p.spanFrom(bracket)), nil
+}
+
+// mapLiteral parses the entries of a map literal after its opening '{'. Where
+// an expression is expected '{' always starts a map; see atMapLiteral for
+// statements.
+func (p *Parser) mapLiteral() (Expr, error) {
+	brace := p.previous()
+	keys := make([]Expr, 0)
+	values := make([]Expr, 0)
+
+	for !p.check(RBRACE) {
+		key, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(COLON, "Expect ':' after map key")
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		values = append(values, value)
+
+		if !p.match(COMMA) {
+			break
+		}
+	}
+
+	_, err := p.consume(RBRACE, "Expect '}' after map entries")
+	if err != nil {
+		return nil, err
+	}
+	return NewMap(brace, keys, values, p.spanFrom(brace)), nil
+}
+
+// atMapLiteral reports whether a statement starting with '{' is a map literal,
+// as when {"a": 1} is typed at the REPL, rather than a block. No statement can
+// begin with a token followed by ':', so one token of key is enough to tell.
+func (p *Parser) atMapLiteral() bool {
+	return p.check(LBRACE) && p.peekAt(2).Type == COLON
+}
+
+func (p *Parser) synchronize() {
+	p.advance()
+
+	for !p.isAtEnd() {
+		if p.previous().Type == SEMICOLON {
+			return
+		}
+		switch p.peek().Type {
+		case CLASS, FUN, VAR, FOR, IF, WHILE, PRINT, RETURN, BREAK, CONTINUE:
+			return
+		}
+		p.advance()
+	}
+}
+
+// spanFrom covers the source from start through the last consumed token.
+func (p *Parser) spanFrom(start Token) Span {
+	return start.Span.To(p.previous().Span)
+}
+
+func (p *Parser) consume(tt TokenType, errMsg string) (Token, error) {
+	if p.check(tt) {
+		return p.advance(), nil
+	}
+	return Token{}, p.error(p.peek(), errMsg)
+}
+
+// error records a syntax error at token and returns it so the caller can
+// unwind to the nearest synchronization point.
+func (p *Parser) error(token Token, message string) ParseError {
+	return p.errorSpan(token, token.Span, message)
+}
+
+// errorSpan is error with the diagnostic underlining span instead of token.
+func (p *Parser) errorSpan(token Token, span Span, message string) ParseError {
+	parseError := NewParseError(token, message)
+	parseError.Err.Span = span
+	parseError.Err.Column = span.Column
+	p.Diagnostics.report(parseError.Err)
+	return parseError
+}
+
+func (p *Parser) match(types ...TokenType) bool {
+	for _, tt := range types {
+		if p.check(tt) {
+			p.advance()
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) check(tt TokenType) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.peek().Type == tt
+}
+
+func (p *Parser) advance() Token {
+	if !p.isAtEnd() {
+		p.last = p.peek()
+		copy(p.lookahead, p.lookahead[1:])
+		p.lookahead = p.lookahead[:len(p.lookahead)-1]
+	}
+	return p.previous()
+}
+
+func (p *Parser) isAtEnd() bool {
+	return p.peek().Type == EOF
+}
+
+func (p *Parser) peek() Token {
+	return p.peekAt(0)
+}
+
+// peekAt returns the token n places past the current one without consuming
+// anything, pulling more tokens from the source as needed.
+func (p *Parser) peekAt(n int) Token {
+	for len(p.lookahead) <= n {
+		p.lookahead = append(p.lookahead, p.Tokens.NextToken())
+	}
+	return p.lookahead[n]
+}
+
+func (p *Parser) previous() Token {
+	return p.last
+}
@@ -0,0 +1,36 @@
+package pyro
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParserLookaheadIsBounded checks that the parser never holds more than
+// two tokens of lookahead, however long an arrow function's parameter list
+// or a grouping is.
+func TestParserLookaheadIsBounded(t *testing.T) {
+	names := make([]string, 200)
+	for i := range names {
+		names[i] = "p" + strings.Repeat("x", i%7) + string(rune('a'+i%26))
+	}
+	// Names repeat, which the resolver would reject but the parser accepts.
+	source := "var f = (" + strings.Join(names, ", ") + ") => 1;\n" +
+		"var g = (((((1 + 2)))));\n" +
+		"var h = (a) => (b) => a + b;\n"
+
+	diagnostics := NewDiagnostics(nil)
+	parser := NewParser(NewScanner(NewSourceFile("lookahead.pyro", source), diagnostics), diagnostics)
+	statements, err := parser.parse()
+	if err != nil || diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %v %v", err, diagnostics.Errors)
+	}
+	if len(statements) != 3 {
+		t.Fatalf("got %d statements, want 3", len(statements))
+	}
+	if parameters := (*statements[0].(Var).Initalizer).(Lambda).Declaration.Params; len(parameters) != 200 {
+		t.Errorf("got %d parameters, want 200", len(parameters))
+	}
+	if cap(parser.lookahead) > 2 {
+		t.Errorf("lookahead grew to %d tokens", cap(parser.lookahead))
+	}
+}
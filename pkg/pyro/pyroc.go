@@ -0,0 +1,1032 @@
+package pyro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// A .pyroc file holds a parsed program so it can run without scanning or
+// parsing. The layout is
+//
+//	magic    "PYROC\x00"
+//	version  u16
+//	length   u32  payload size in bytes
+//	checksum u32  CRC-32 (IEEE) of the payload
+//	payload:
+//	  constants   count, then per entry a tag byte and the value
+//	  spans       count, then start, length, line and column per entry
+//	  file name   constant index
+//	  statements  count, then one node per statement
+//
+// Integers inside the payload are unsigned varints. Nodes start with a tag
+// byte; tokens and literal values refer to the constant pool and every
+// location refers to the span table. The source text is not stored, so
+// diagnostics for a loaded program show the location without the line.
+//
+// Tokens are stored by TokenType number, so pyrocVersion must be bumped
+// whenever that numbering or the encoding of an existing node changes.
+// Version 2 added lists, version 3 maps, version 4 break and continue,
+// version 5 lambdas, version 6 string interpolation, version 7 the
+// exponent, integer division and bitwise operators, version 8
+// conditional and null-coalescing expressions, version 9 compound
+// assignment, ++ and -- and version 10 integer literals. Version 11 added
+// decimal literals.
+const (
+	pyrocMagic      = "PYROC\x00"
+	pyrocVersion    = 11
+	pyrocHeaderSize = len(pyrocMagic) + 2 + 4 + 4
+	maxNodeDepth    = 10000
+)
+
+const (
+	constString byte = iota + 1
+	constFloat
+	constInteger
+	constBigInteger
+	constDecimal
+)
+
+const (
+	literalNil byte = iota
+	literalTrue
+	literalFalse
+	literalFloat
+	literalString
+	literalInteger
+	literalDecimal
+)
+
+const (
+	nodeVar byte = iota + 1
+	nodePrint
+	nodeExpression
+	nodeBlock
+	nodeIf
+	nodeWhile
+	nodeFunction
+	nodeReturn
+	nodeClass
+	nodeBreak
+	nodeContinue
+)
+
+// Expression tags start at 32 so new statements can be added without
+// renumbering them.
+const (
+	nodeBinary byte = iota + 32
+	nodeUnary
+	nodeLiteral
+	nodeGrouping
+	nodeVariable
+	nodeAssign
+	nodeLogical
+	nodeCall
+	nodeGet
+	nodeSet
+	nodeThis
+	nodeSuper
+	nodeList
+	nodeSubscript
+	nodeSubscriptSet
+	nodeMap
+	nodeLambda
+	nodeInterpolation
+	nodeConditional
+	nodeCoalesce
+	nodeCompoundAssign
+)
+
+// FormatError reports a .pyroc file that cannot be loaded.
+type FormatError struct {
+	Message string
+}
+
+func (fe FormatError) Error() string {
+	return "pyroc: " + fe.Message
+}
+
+// IsCompiled reports whether data starts with the .pyroc magic header.
+func IsCompiled(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(pyrocMagic))
+}
+
+type spanKey struct {
+	start, end, line, column int
+}
+
+// programEncoder writes statements in the .pyroc format. It visits the tree
+// like the AstPrinter does, appending to body as it goes.
+type programEncoder struct {
+	body          bytes.Buffer
+	constants     []interface{}
+	constantIndex map[interface{}]int
+	spans         []Span
+	spanIndex     map[spanKey]int
+}
+
+func encodeProgram(name string, statements []Stmt) []byte {
+	e := &programEncoder{
+		constants:     make([]interface{}, 0),
+		constantIndex: make(map[interface{}]int),
+		spans:         make([]Span, 0),
+		spanIndex:     make(map[spanKey]int),
+	}
+
+	e.writeUint(e.constant(name))
+	e.stmts(statements)
+
+	var payload bytes.Buffer
+	putUvarint(&payload, uint64(len(e.constants)))
+	for _, constant := range e.constants {
+		switch c := constant.(type) {
+		case string:
+			payload.WriteByte(constString)
+			putUvarint(&payload, uint64(len(c)))
+			payload.WriteString(c)
+		case float64:
+			payload.WriteByte(constFloat)
+			binary.Write(&payload, binary.BigEndian, math.Float64bits(c))
+		case int64:
+			payload.WriteByte(constInteger)
+			var scratch [binary.MaxVarintLen64]byte
+			payload.Write(scratch[:binary.PutVarint(scratch[:], c)])
+		case *big.Int:
+			text := c.String()
+			payload.WriteByte(constBigInteger)
+			putUvarint(&payload, uint64(len(text)))
+			payload.WriteString(text)
+		case *PyroDecimal:
+			text := c.String()
+			payload.WriteByte(constDecimal)
+			putUvarint(&payload, uint64(len(text)))
+			payload.WriteString(text)
+		}
+	}
+	putUvarint(&payload, uint64(len(e.spans)))
+	for _, span := range e.spans {
+		putUvarint(&payload, uint64(span.Start))
+		putUvarint(&payload, uint64(span.End-span.Start))
+		putUvarint(&payload, uint64(span.Line))
+		putUvarint(&payload, uint64(span.Column))
+	}
+	payload.Write(e.body.Bytes())
+
+	var out bytes.Buffer
+	out.WriteString(pyrocMagic)
+	binary.Write(&out, binary.BigEndian, uint16(pyrocVersion))
+	binary.Write(&out, binary.BigEndian, uint32(payload.Len()))
+	binary.Write(&out, binary.BigEndian, crc32.ChecksumIEEE(payload.Bytes()))
+	out.Write(payload.Bytes())
+	return out.Bytes()
+}
+
+func putUvarint(buf *bytes.Buffer, value uint64) {
+	var scratch [binary.MaxVarintLen64]byte
+	buf.Write(scratch[:binary.PutUvarint(scratch[:], value)])
+}
+
+func (e *programEncoder) writeUint(value int) {
+	putUvarint(&e.body, uint64(value))
+}
+
+func (e *programEncoder) writeFlag(set bool) {
+	if set {
+		e.body.WriteByte(1)
+	} else {
+		e.body.WriteByte(0)
+	}
+}
+
+func (e *programEncoder) constant(value interface{}) int {
+	if index, exists := e.constantIndex[value]; exists {
+		return index
+	}
+	e.constantIndex[value] = len(e.constants)
+	e.constants = append(e.constants, value)
+	return len(e.constants) - 1
+}
+
+func (e *programEncoder) writeSpan(span Span) {
+	key := spanKey{span.Start, span.End, span.Line, span.Column}
+	index, exists := e.spanIndex[key]
+	if !exists {
+		index = len(e.spans)
+		e.spanIndex[key] = index
+		e.spans = append(e.spans, span)
+	}
+	e.writeUint(index)
+}
+
+func (e *programEncoder) writeToken(token Token) {
+	e.writeUint(int(token.Type))
+	e.writeUint(e.constant(token.Lexeme))
+	e.writeSpan(token.Span)
+}
+
+func (e *programEncoder) stmts(statements []Stmt) {
+	e.writeUint(len(statements))
+	for _, statement := range statements {
+		statement.Accept(e)
+	}
+}
+
+func (e *programEncoder) expr(expr Expr) {
+	expr.Accept(e)
+}
+
+func (e *programEncoder) function(stmt Function) {
+	e.writeSpan(stmt.Location)
+	e.writeToken(stmt.Name)
+	e.writeUint(len(stmt.Params))
+	for _, param := range stmt.Params {
+		e.writeToken(param)
+	}
+	e.stmts(stmt.Body)
+}
+
+func (e *programEncoder) VisitVarStmt(stmt Var) error {
+	e.body.WriteByte(nodeVar)
+	e.writeSpan(stmt.Location)
+	e.writeToken(stmt.Name)
+	e.writeFlag(stmt.Initalizer != nil)
+	if stmt.Initalizer != nil {
+		e.expr(*stmt.Initalizer)
+	}
+	return nil
+}
+
+func (e *programEncoder) VisitPrintStmt(stmt Print) error {
+	e.body.WriteByte(nodePrint)
+	e.writeSpan(stmt.Location)
+	e.expr(stmt.Expression)
+	return nil
+}
+
+func (e *programEncoder) VisitExpressionStmt(stmt Expression) error {
+	e.body.WriteByte(nodeExpression)
+	e.writeSpan(stmt.Location)
+	e.expr(stmt.Expression)
+	return nil
+}
+
+func (e *programEncoder) VisitBlockStmt(stmt Block) error {
+	e.body.WriteByte(nodeBlock)
+	e.writeSpan(stmt.Location)
+	e.stmts(stmt.Statements)
+	return nil
+}
+
+func (e *programEncoder) VisitIfStmt(stmt If) error {
+	e.body.WriteByte(nodeIf)
+	e.writeSpan(stmt.Location)
+	e.expr(stmt.Condition)
+	stmt.ThenBranch.Accept(e)
+	e.writeFlag(stmt.ElseBranch != nil)
+	if stmt.ElseBranch != nil {
+		(*stmt.ElseBranch).Accept(e)
+	}
+	return nil
+}
+
+func (e *programEncoder) VisitWhileStmt(stmt While) error {
+	e.body.WriteByte(nodeWhile)
+	e.writeSpan(stmt.Location)
+	e.expr(stmt.Condition)
+	stmt.Body.Accept(e)
+	e.writeFlag(stmt.Increment != nil)
+	if stmt.Increment != nil {
+		e.expr(*stmt.Increment)
+	}
+	return nil
+}
+
+func (e *programEncoder) VisitBreakStmt(stmt Break) error {
+	e.body.WriteByte(nodeBreak)
+	e.writeSpan(stmt.Location)
+	e.writeToken(stmt.Keyword)
+	return nil
+}
+
+func (e *programEncoder) VisitContinueStmt(stmt Continue) error {
+	e.body.WriteByte(nodeContinue)
+	e.writeSpan(stmt.Location)
+	e.writeToken(stmt.Keyword)
+	return nil
+}
+
+func (e *programEncoder) VisitFunctionStmt(stmt Function) error {
+	e.body.WriteByte(nodeFunction)
+	e.function(stmt)
+	return nil
+}
+
+func (e *programEncoder) VisitReturnStmt(stmt Return) error {
+	e.body.WriteByte(nodeReturn)
+	e.writeSpan(stmt.Location)
+	e.writeToken(stmt.Keyword)
+	e.writeFlag(stmt.Value != nil)
+	if stmt.Value != nil {
+		e.expr(*stmt.Value)
+	}
+	return nil
+}
+
+func (e *programEncoder) VisitClassStmt(stmt Class) error {
+	e.body.WriteByte(nodeClass)
+	e.writeSpan(stmt.Location)
+	e.writeToken(stmt.Name)
+	e.writeFlag(stmt.Superclass != nil)
+	if stmt.Superclass != nil {
+		e.writeToken(stmt.Superclass.Name)
+	}
+	e.writeUint(len(stmt.Methods))
+	for _, method := range stmt.Methods {
+		e.function(method)
+	}
+	return nil
+}
+
+func (e *programEncoder) VisitBinaryExpr(expr Binary) (interface{}, error) {
+	e.body.WriteByte(nodeBinary)
+	e.expr(expr.Left)
+	e.writeToken(expr.Operator)
+	e.expr(expr.Right)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitUnaryExpr(expr Unary) (interface{}, error) {
+	e.body.WriteByte(nodeUnary)
+	e.writeToken(expr.Operator)
+	e.expr(expr.Right)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitLiteralExpr(expr Literal) (interface{}, error) {
+	e.body.WriteByte(nodeLiteral)
+	e.writeSpan(expr.Location)
+	switch value := expr.Value.(type) {
+	case nil:
+		e.body.WriteByte(literalNil)
+	case bool:
+		if value {
+			e.body.WriteByte(literalTrue)
+		} else {
+			e.body.WriteByte(literalFalse)
+		}
+	case float64:
+		e.body.WriteByte(literalFloat)
+		e.writeUint(e.constant(value))
+	case int64, *big.Int:
+		e.body.WriteByte(literalInteger)
+		e.writeUint(e.constant(value))
+	case *PyroDecimal:
+		e.body.WriteByte(literalDecimal)
+		e.writeUint(e.constant(value))
+	case string:
+		e.body.WriteByte(literalString)
+		e.writeUint(e.constant(value))
+	}
+	return nil, nil
+}
+
+func (e *programEncoder) VisitGroupingExpr(expr Grouping) (interface{}, error) {
+	e.body.WriteByte(nodeGrouping)
+	e.expr(expr.Expression)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitVariableExpr(expr Variable) (interface{}, error) {
+	e.body.WriteByte(nodeVariable)
+	e.writeToken(expr.Name)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitAssignExpr(expr Assign) (interface{}, error) {
+	e.body.WriteByte(nodeAssign)
+	e.writeToken(expr.Name)
+	e.expr(expr.Value)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitLogicalExpr(expr Logical) (interface{}, error) {
+	e.body.WriteByte(nodeLogical)
+	e.expr(expr.Left)
+	e.writeToken(expr.Operator)
+	e.expr(expr.Right)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitConditionalExpr(expr Conditional) (interface{}, error) {
+	e.body.WriteByte(nodeConditional)
+	e.expr(expr.Condition)
+	e.expr(expr.ThenBranch)
+	e.expr(expr.ElseBranch)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitCoalesceExpr(expr Coalesce) (interface{}, error) {
+	e.body.WriteByte(nodeCoalesce)
+	e.expr(expr.Left)
+	e.writeToken(expr.Operator)
+	e.expr(expr.Right)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, error) {
+	e.body.WriteByte(nodeCompoundAssign)
+	e.expr(expr.Target)
+	e.writeToken(expr.Operator)
+	e.expr(expr.Value)
+	e.writeFlag(expr.Postfix)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitCallExpr(expr Call) (interface{}, error) {
+	e.body.WriteByte(nodeCall)
+	e.expr(expr.Callee)
+	e.writeToken(expr.Paren)
+	e.writeUint(len(expr.Arguments))
+	for _, argument := range expr.Arguments {
+		e.expr(argument)
+	}
+	return nil, nil
+}
+
+func (e *programEncoder) VisitGetExpr(expr Get) (interface{}, error) {
+	e.body.WriteByte(nodeGet)
+	e.expr(expr.Object)
+	e.writeToken(expr.Name)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitSetExpr(expr Set) (interface{}, error) {
+	e.body.WriteByte(nodeSet)
+	e.expr(expr.Object)
+	e.writeToken(expr.Name)
+	e.expr(expr.Value)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitThisExpr(expr This) (interface{}, error) {
+	e.body.WriteByte(nodeThis)
+	e.writeToken(expr.Keyword)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitSuperExpr(expr Super) (interface{}, error) {
+	e.body.WriteByte(nodeSuper)
+	e.writeToken(expr.Keyword)
+	e.writeToken(expr.Method)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitListExpr(expr List) (interface{}, error) {
+	e.body.WriteByte(nodeList)
+	e.writeSpan(expr.Location)
+	e.writeUint(len(expr.Elements))
+	for _, element := range expr.Elements {
+		e.expr(element)
+	}
+	return nil, nil
+}
+
+func (e *programEncoder) VisitMapExpr(expr Map) (interface{}, error) {
+	e.body.WriteByte(nodeMap)
+	e.writeToken(expr.Brace)
+	e.writeSpan(expr.Location)
+	e.writeUint(len(expr.Keys))
+	for i := range expr.Keys {
+		e.expr(expr.Keys[i])
+		e.expr(expr.Values[i])
+	}
+	return nil, nil
+}
+
+func (e *programEncoder) VisitInterpolationExpr(expr Interpolation) (interface{}, error) {
+	e.body.WriteByte(nodeInterpolation)
+	e.writeSpan(expr.Location)
+	e.writeUint(len(expr.Parts))
+	for _, part := range expr.Parts {
+		e.expr(part)
+	}
+	return nil, nil
+}
+
+func (e *programEncoder) VisitLambdaExpr(expr Lambda) (interface{}, error) {
+	e.body.WriteByte(nodeLambda)
+	e.function(expr.Declaration)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitSubscriptExpr(expr Subscript) (interface{}, error) {
+	e.body.WriteByte(nodeSubscript)
+	e.expr(expr.Object)
+	e.writeToken(expr.Bracket)
+	e.expr(expr.Index)
+	return nil, nil
+}
+
+func (e *programEncoder) VisitSubscriptSetExpr(expr SubscriptSet) (interface{}, error) {
+	e.body.WriteByte(nodeSubscriptSet)
+	e.expr(expr.Object)
+	e.writeToken(expr.Bracket)
+	e.expr(expr.Index)
+	e.expr(expr.Value)
+	return nil, nil
+}
+
+// programDecoder reads the .pyroc format back into statements. The first
+// problem found is kept in err; after that every read returns zero values so
+// callers can check once at the end.
+type programDecoder struct {
+	data      []byte
+	pos       int
+	constants []interface{}
+	spans     []Span
+	file      *SourceFile
+	depth     int
+	err       error
+}
+
+func decodeProgram(data []byte) ([]Stmt, error) {
+	if !IsCompiled(data) {
+		return nil, FormatError{Message: "not a compiled Pyro program (bad magic header)"}
+	}
+	if len(data) < pyrocHeaderSize {
+		return nil, FormatError{Message: "file is truncated inside the header"}
+	}
+
+	header := data[len(pyrocMagic):pyrocHeaderSize]
+	version := binary.BigEndian.Uint16(header[0:2])
+	length := binary.BigEndian.Uint32(header[2:6])
+	checksum := binary.BigEndian.Uint32(header[6:10])
+	if version != pyrocVersion {
+		return nil, FormatError{Message: "format version " + strconv.Itoa(int(version)) +
+			" is not supported (this build reads version " + strconv.Itoa(pyrocVersion) + "); recompile the source"}
+	}
+
+	payload := data[pyrocHeaderSize:]
+	if uint64(len(payload)) < uint64(length) {
+		return nil, FormatError{Message: "file is truncated: expected " + strconv.FormatUint(uint64(length), 10) +
+			" bytes of program but found " + strconv.Itoa(len(payload))}
+	}
+	if uint64(len(payload)) > uint64(length) {
+		return nil, FormatError{Message: "unexpected data after the end of the program"}
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, FormatError{Message: "checksum mismatch; the file is corrupt"}
+	}
+
+	d := &programDecoder{data: payload}
+	d.readConstants()
+	d.readSpans()
+	d.file = NewSourceFile(d.readString(), "")
+	statements := d.stmts()
+	if d.err == nil && d.pos != len(d.data) {
+		d.fail("unexpected data after the last statement")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return statements, nil
+}
+
+func (d *programDecoder) fail(message string) {
+	if d.err == nil {
+		d.err = FormatError{Message: message + " at offset " + strconv.Itoa(pyrocHeaderSize+d.pos)}
+	}
+}
+
+func (d *programDecoder) readByte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if d.pos >= len(d.data) {
+		d.fail("unexpected end of data")
+		return 0
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b
+}
+
+func (d *programDecoder) readUint() int {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 || value > math.MaxInt32 {
+		d.fail("malformed integer")
+		return 0
+	}
+	d.pos += n
+	return int(value)
+}
+
+// readCount reads a length prefix, rejecting values that could not fit in the
+// remaining data so a corrupt file cannot force a huge allocation.
+func (d *programDecoder) readCount() int {
+	n := d.readUint()
+	if n > len(d.data)-d.pos {
+		d.fail("length " + strconv.Itoa(n) + " exceeds the remaining data")
+		return 0
+	}
+	return n
+}
+
+func (d *programDecoder) readFlag() bool {
+	switch d.readByte() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	d.fail("malformed flag")
+	return false
+}
+
+func (d *programDecoder) readConstants() {
+	n := d.readCount()
+	d.constants = make([]interface{}, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		switch tag := d.readByte(); tag {
+		case constString:
+			size := d.readCount()
+			if d.err != nil {
+				return
+			}
+			d.constants = append(d.constants, string(d.data[d.pos:d.pos+size]))
+			d.pos += size
+		case constFloat:
+			if len(d.data)-d.pos < 8 {
+				d.fail("unexpected end of data")
+				return
+			}
+			d.constants = append(d.constants, math.Float64frombits(binary.BigEndian.Uint64(d.data[d.pos:])))
+			d.pos += 8
+		case constInteger:
+			value, n := binary.Varint(d.data[d.pos:])
+			if n <= 0 {
+				d.fail("malformed integer")
+				return
+			}
+			d.constants = append(d.constants, value)
+			d.pos += n
+		case constBigInteger:
+			size := d.readCount()
+			if d.err != nil {
+				return
+			}
+			value, ok := new(big.Int).SetString(string(d.data[d.pos:d.pos+size]), 10)
+			if !ok || value.IsInt64() {
+				d.fail("malformed big integer")
+				return
+			}
+			d.constants = append(d.constants, value)
+			d.pos += size
+		case constDecimal:
+			size := d.readCount()
+			if d.err != nil {
+				return
+			}
+			text := string(d.data[d.pos : d.pos+size])
+			value, err := ParseDecimal(text)
+			if err != nil || value.String() != text {
+				d.fail("malformed decimal")
+				return
+			}
+			d.constants = append(d.constants, value)
+			d.pos += size
+		default:
+			d.fail("unknown constant tag " + strconv.Itoa(int(tag)))
+		}
+	}
+}
+
+func (d *programDecoder) readSpans() {
+	n := d.readCount()
+	d.spans = make([]Span, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		start := d.readUint()
+		length := d.readUint()
+		d.spans = append(d.spans, Span{
+			Start:  start,
+			End:    start + length,
+			Line:   d.readUint(),
+			Column: d.readUint(),
+		})
+	}
+}
+
+func (d *programDecoder) readConstant() interface{} {
+	index := d.readUint()
+	if d.err != nil {
+		return nil
+	}
+	if index >= len(d.constants) {
+		d.fail("constant index " + strconv.Itoa(index) + " out of range")
+		return nil
+	}
+	return d.constants[index]
+}
+
+func (d *programDecoder) readString() string {
+	value := d.readConstant()
+	str, isString := value.(string)
+	if !isString && d.err == nil {
+		d.fail("expected a string constant")
+	}
+	return str
+}
+
+func (d *programDecoder) readFloat() float64 {
+	value := d.readConstant()
+	num, isFloat := value.(float64)
+	if !isFloat && d.err == nil {
+		d.fail("expected a float constant")
+	}
+	return num
+}
+
+func (d *programDecoder) readInteger() interface{} {
+	value := d.readConstant()
+	if !isInteger(value) && d.err == nil {
+		d.fail("expected an integer constant")
+	}
+	return value
+}
+
+func (d *programDecoder) readDecimal() *PyroDecimal {
+	value := d.readConstant()
+	num, isDecimal := value.(*PyroDecimal)
+	if !isDecimal && d.err == nil {
+		d.fail("expected a decimal constant")
+	}
+	return num
+}
+
+func (d *programDecoder) readSpan() Span {
+	index := d.readUint()
+	if d.err != nil {
+		return Span{}
+	}
+	if index >= len(d.spans) {
+		d.fail("span index " + strconv.Itoa(index) + " out of range")
+		return Span{}
+	}
+	span := d.spans[index]
+	span.File = d.file
+	return span
+}
+
+func (d *programDecoder) readToken() Token {
+	tt := d.readUint()
+	if tt > int(EOF) {
+		d.fail("unknown token type " + strconv.Itoa(tt))
+	}
+	lexeme := d.readString()
+	return NewToken(TokenType(tt), lexeme, d.readSpan())
+}
+
+func (d *programDecoder) stmts() []Stmt {
+	n := d.readCount()
+	statements := make([]Stmt, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		statements = append(statements, d.stmt())
+	}
+	return statements
+}
+
+func (d *programDecoder) enter() bool {
+	d.depth++
+	if d.depth > maxNodeDepth {
+		d.fail("program is nested too deeply")
+		return false
+	}
+	return d.err == nil
+}
+
+func (d *programDecoder) function() Function {
+	span := d.readSpan()
+	name := d.readToken()
+	params := make([]Token, d.readCount())
+	for i := range params {
+		params[i] = d.readToken()
+	}
+	return NewFunction(name, params, d.stmts(), span)
+}
+
+func (d *programDecoder) stmt() Stmt {
+	if !d.enter() {
+		return nil
+	}
+	defer func() { d.depth-- }()
+
+	switch tag := d.readByte(); tag {
+	case nodeVar:
+		span := d.readSpan()
+		name := d.readToken()
+		var initializer *Expr
+		if d.readFlag() {
+			expr := d.expr()
+			initializer = &expr
+		}
+		return NewVar(name, initializer, span)
+	case nodePrint:
+		span := d.readSpan()
+		return NewPrint(d.expr(), span)
+	case nodeExpression:
+		span := d.readSpan()
+		return NewExpression(d.expr(), span)
+	case nodeBlock:
+		span := d.readSpan()
+		return NewBlock(d.stmts(), span)
+	case nodeIf:
+		span := d.readSpan()
+		condition := d.expr()
+		thenBranch := d.stmt()
+		var elseBranch *Stmt
+		if d.readFlag() {
+			stmt := d.stmt()
+			elseBranch = &stmt
+		}
+		return NewIf(condition, thenBranch, elseBranch, span)
+	case nodeWhile:
+		span := d.readSpan()
+		condition := d.expr()
+		body := d.stmt()
+		var increment *Expr
+		if d.readFlag() {
+			expr := d.expr()
+			increment = &expr
+		}
+		return NewWhile(condition, body, increment, span)
+	case nodeFunction:
+		return d.function()
+	case nodeReturn:
+		span := d.readSpan()
+		keyword := d.readToken()
+		var value *Expr
+		if d.readFlag() {
+			expr := d.expr()
+			value = &expr
+		}
+		return NewReturn(keyword, value, span)
+	case nodeClass:
+		span := d.readSpan()
+		name := d.readToken()
+		var superclass *Variable
+		if d.readFlag() {
+			variable := NewVariable(d.readToken())
+			superclass = &variable
+		}
+		methods := make([]Function, d.readCount())
+		for i := range methods {
+			methods[i] = d.function()
+		}
+		return NewClass(name, superclass, methods, span)
+	case nodeBreak:
+		span := d.readSpan()
+		return NewBreak(d.readToken(), span)
+	case nodeContinue:
+		span := d.readSpan()
+		return NewContinue(d.readToken(), span)
+	default:
+		d.fail("unknown statement tag " + strconv.Itoa(int(tag)))
+		return nil
+	}
+}
+
+func (d *programDecoder) expr() Expr {
+	if !d.enter() {
+		return nil
+	}
+	defer func() { d.depth-- }()
+
+	switch tag := d.readByte(); tag {
+	case nodeBinary:
+		left := d.expr()
+		operator := d.readToken()
+		return NewBinary(left, operator, d.expr())
+	case nodeUnary:
+		operator := d.readToken()
+		return NewUnary(operator, d.expr())
+	case nodeLiteral:
+		span := d.readSpan()
+		switch kind := d.readByte(); kind {
+		case literalNil:
+			return NewLiteral(nil, span)
+		case literalTrue:
+			return NewLiteral(true, span)
+		case literalFalse:
+			return NewLiteral(false, span)
+		case literalFloat:
+			return NewLiteral(d.readFloat(), span)
+		case literalInteger:
+			return NewLiteral(d.readInteger(), span)
+		case literalDecimal:
+			return NewLiteral(d.readDecimal(), span)
+		case literalString:
+			return NewLiteral(d.readString(), span)
+		default:
+			d.fail("unknown literal kind " + strconv.Itoa(int(kind)))
+			return nil
+		}
+	case nodeGrouping:
+		return NewGrouping(d.expr())
+	case nodeVariable:
+		return NewVariable(d.readToken())
+	case nodeAssign:
+		name := d.readToken()
+		return NewAssign(name, d.expr())
+	case nodeLogical:
+		left := d.expr()
+		operator := d.readToken()
+		return NewLogical(left, operator, d.expr())
+	case nodeCall:
+		callee := d.expr()
+		paren := d.readToken()
+		arguments := make([]Expr, d.readCount())
+		for i := range arguments {
+			arguments[i] = d.expr()
+		}
+		return NewCall(callee, paren, arguments)
+	case nodeGet:
+		object := d.expr()
+		return NewGet(object, d.readToken())
+	case nodeSet:
+		object := d.expr()
+		name := d.readToken()
+		return NewSet(object, name, d.expr())
+	case nodeThis:
+		return NewThis(d.readToken())
+	case nodeSuper:
+		keyword := d.readToken()
+		return NewSuper(keyword, d.readToken())
+	case nodeList:
+		span := d.readSpan()
+		elements := make([]Expr, d.readCount())
+		for i := range elements {
+			elements[i] = d.expr()
+		}
+		return NewList(elements, span)
+	case nodeMap:
+		brace := d.readToken()
+		span := d.readSpan()
+		n := d.readCount()
+		keys := make([]Expr, 0, n)
+		values := make([]Expr, 0, n)
+		for i := 0; i < n && d.err == nil; i++ {
+			keys = append(keys, d.expr())
+			values = append(values, d.expr())
+		}
+		return NewMap(brace, keys, values, span)
+	case nodeSubscript:
+		object := d.expr()
+		bracket := d.readToken()
+		return NewSubscript(object, bracket, d.expr())
+	case nodeSubscriptSet:
+		object := d.expr()
+		bracket := d.readToken()
+		index := d.expr()
+		return NewSubscriptSet(object, bracket, index, d.expr())
+	case nodeLambda:
+		return NewLambda(d.function())
+	case nodeConditional:
+		condition := d.expr()
+		thenBranch := d.expr()
+		return NewConditional(condition, thenBranch, d.expr())
+	case nodeCoalesce:
+		left := d.expr()
+		operator := d.readToken()
+		return NewCoalesce(left, operator, d.expr())
+	case nodeCompoundAssign:
+		target := d.expr()
+		operator := d.readToken()
+		value := d.expr()
+		postfix := d.readFlag()
+		switch target.(type) {
+		case Variable, Get, Subscript:
+		default:
+			d.fail("invalid assignment target")
+		}
+		if _, isCompound := compoundOperators[operator.Type]; !isCompound {
+			d.fail("invalid compound assignment operator")
+		}
+		return NewCompoundAssign(target, operator, value, postfix)
+	case nodeInterpolation:
+		span := d.readSpan()
+		parts := make([]Expr, d.readCount())
+		for i := range parts {
+			parts[i] = d.expr()
+		}
+		return NewInterpolation(parts, span)
+	default:
+		d.fail("unknown expression tag " + strconv.Itoa(int(tag)))
+		return nil
+	}
+}
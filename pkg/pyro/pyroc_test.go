@@ -0,0 +1,184 @@
+package pyro
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// everyNode uses every statement and expression node, and every kind of
+// literal, so a round trip through the .pyroc format exercises the whole
+// encoder and decoder.
+const everyNode = `
+var none = nil;
+none = nil;
+var flags = [true, false];
+var nums = [1, 1.5, 123456789012345678901234567890, 12.50d];
+class Shape {
+	init(name) { this.name = name; }
+	describe() { return "shape " + this.name; }
+}
+class Square < Shape {
+	init(side) { super.init("square"); this.side = side; }
+	describe() { return super.describe() + " of side ${this.side}"; }
+}
+fun total(xs) {
+	var sum = 0;
+	for (var i = 0; i < len(xs); i++) {
+		if (xs[i] == nil) continue;
+		if (xs[i] > 100) break;
+		sum += xs[i];
+	}
+	return sum;
+}
+var scores = {"a": 1, "b": 2};
+scores["c"] = 3;
+var double = (x) => x * 2;
+var triple = fun (x) { return x * 3; };
+{
+	var square = Square(4);
+	print square.describe();
+	print total([1, nil, 2, 500, 3]);
+	print -(double(2) + triple(1)) ** 2 // 3;
+	print !none and (nums[0] < 2 or false);
+	print none ?? "default";
+	print len(scores) > 2 ? "many" : "few";
+	while (false) {}
+	print flags;
+	print nums;
+}
+`
+
+func TestPyrocRoundTrip(t *testing.T) {
+	diagnostics := NewDiagnostics(nil)
+	statements, err := NewParser(NewScanner(NewSourceFile("every.pyro", everyNode), diagnostics), diagnostics).parse()
+	if err != nil || diagnostics.HasErrors() {
+		t.Fatalf("parse failed: %v %v", err, diagnostics.Errors)
+	}
+	// The parser drops parentheses, so the one Grouping node is built by hand.
+	statements = append(statements, NewExpression(NewGrouping(NewLiteral("grouped", Span{})), Span{}))
+
+	seen := make(map[string]bool)
+	collectNodes(reflect.ValueOf(statements), seen)
+	for _, name := range []string{
+		"Var", "Print", "Expression", "Block", "If", "While", "Function", "Return", "Class", "Break", "Continue",
+		"Binary", "Unary", "Literal", "Grouping", "Variable", "Assign", "Logical", "Conditional", "Coalesce",
+		"CompoundAssign", "Call", "Get", "Set", "This", "Super", "List", "Map", "Subscript", "SubscriptSet",
+		"Lambda", "Interpolation",
+	} {
+		if !seen[name] {
+			t.Errorf("test program has no %s node", name)
+		}
+	}
+
+	decoded, err := decodeProgram(encodeProgram("every.pyro", statements))
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	printer := AstPrinter{}
+	for i, statement := range statements {
+		if want, got := printer.PrintStmt(statement), printer.PrintStmt(decoded[i]); want != got {
+			t.Errorf("statement %d changed in the round trip:\nwant %s\ngot  %s", i, want, got)
+		}
+	}
+	if len(decoded) != len(statements) {
+		t.Errorf("decoded %d statements, want %d", len(decoded), len(statements))
+	}
+
+	for _, useVM := range []bool{false, true} {
+		want := runEngine(t, useVM, func(e *Engine) (Value, error) { return e.Eval(everyNode) })
+		compiled, err := NewEngineWithDiagnostics(NewDiagnostics(nil)).Compile("every.pyro", everyNode)
+		if err != nil {
+			t.Fatalf("compile failed: %v", err)
+		}
+		got := runEngine(t, useVM, func(e *Engine) (Value, error) { return e.EvalCompiled(compiled) })
+		if want != got {
+			t.Errorf("vm=%v: compiled program printed\n%s\nwant\n%s", useVM, got, want)
+		}
+	}
+}
+
+// collectNodes records the type name of every Stmt and Expr reachable from v.
+func collectNodes(v reflect.Value, seen map[string]bool) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			collectNodes(v.Elem(), seen)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectNodes(v.Index(i), seen)
+		}
+	case reflect.Struct:
+		value := v.Interface()
+		_, isStmt := value.(Stmt)
+		_, isExpr := value.(Expr)
+		if isStmt || isExpr {
+			seen[v.Type().Name()] = true
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				collectNodes(v.Field(i), seen)
+			}
+		}
+	}
+}
+
+func runEngine(t *testing.T, useVM bool, run func(*Engine) (Value, error)) string {
+	t.Helper()
+	var out bytes.Buffer
+	engine := NewEngineWithDiagnostics(NewDiagnostics(nil))
+	engine.UseVM(useVM)
+	engine.SetStdout(&out)
+	if _, err := run(engine); err != nil {
+		t.Fatalf("vm=%v: run failed: %v", useVM, err)
+	}
+	return out.String()
+}
+
+func TestPyrocRejectsBadFiles(t *testing.T) {
+	valid, err := NewEngineWithDiagnostics(NewDiagnostics(nil)).Compile("ok.pyro", `print "ok";`)
+	if err != nil {
+		t.Fatalf("compile failed: %v", err)
+	}
+	modified := func(change func([]byte) []byte) []byte {
+		return change(append([]byte(nil), valid...))
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"empty", nil, "bad magic header"},
+		{"bad magic", modified(func(b []byte) []byte { b[0] = 'X'; return b }), "bad magic header"},
+		{"source file", []byte(`print "ok";`), "bad magic header"},
+		{"truncated header", valid[:len(pyrocMagic)+3], "truncated inside the header"},
+		{"older version", modified(func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[len(pyrocMagic):], pyrocVersion-1)
+			return b
+		}), "is not supported (this build reads version"},
+		{"newer version", modified(func(b []byte) []byte {
+			binary.BigEndian.PutUint16(b[len(pyrocMagic):], pyrocVersion+1)
+			return b
+		}), "is not supported (this build reads version"},
+		{"truncated payload", valid[:len(valid)-3], "file is truncated: expected"},
+		{"header only", valid[:pyrocHeaderSize], "file is truncated: expected"},
+		{"trailing data", modified(func(b []byte) []byte { return append(b, 0) }), "unexpected data after the end of the program"},
+		{"checksum mismatch", modified(func(b []byte) []byte { b[len(b)-1] ^= 0xFF; return b }), "checksum mismatch"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewEngineWithDiagnostics(NewDiagnostics(nil)).EvalCompiled(test.data)
+			formatErr, isFormat := err.(FormatError)
+			if !isFormat {
+				t.Fatalf("got %T %v, want a FormatError", err, err)
+			}
+			if !strings.Contains(formatErr.Error(), test.want) {
+				t.Errorf("got %q, want it to mention %q", formatErr.Error(), test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,447 @@
+package pyro
+
+type FunctionType int
+
+const (
+	NONE_FUNCTION FunctionType = iota
+	FUNCTION
+	METHOD
+	INITIALIZER
+)
+
+type ClassType int
+
+const (
+	NONE_CLASS ClassType = iota
+	IN_CLASS
+	IN_SUBCLASS
+)
+
+// Resolver walks the program once before it runs, reporting static errors
+// and recording in Locals how many scopes away each local variable lives.
+type Resolver struct {
+	Locals          map[ExprID]int
+	Diagnostics     *Diagnostics
+	Scopes          []map[string]bool
+	CurrentFunction FunctionType
+	CurrentClass    ClassType
+	// LoopDepth counts the loops enclosing the current statement within the
+	// current function; break and continue need at least one.
+	LoopDepth int
+}
+
+func NewResolver(diagnostics *Diagnostics, locals map[ExprID]int) *Resolver {
+	return &Resolver{
+		Locals:          locals,
+		Diagnostics:     diagnostics,
+		Scopes:          make([]map[string]bool, 0),
+		CurrentFunction: NONE_FUNCTION,
+		CurrentClass:    NONE_CLASS,
+	}
+}
+
+func (r *Resolver) resolve(statements []Stmt) error {
+	for _, statement := range statements {
+		err := r.resolveStmt(statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Resolver) resolveStmt(stmt Stmt) error {
+	return stmt.Accept(r)
+}
+
+func (r *Resolver) resolveExpr(expr Expr) error {
+	_, err := expr.Accept(r)
+	return err
+}
+
+func (r *Resolver) error(token Token, message string) {
+	resolveError := NewParseError(token, message)
+	resolveError.Err.Kind = RESOLVE_ERROR
+	r.Diagnostics.report(resolveError.Err)
+}
+
+func (r *Resolver) beginScope() {
+	r.Scopes = append(r.Scopes, make(map[string]bool))
+}
+
+func (r *Resolver) endScope() {
+	r.Scopes = r.Scopes[:len(r.Scopes)-1]
+}
+
+func (r *Resolver) declare(name Token) {
+	if len(r.Scopes) == 0 {
+		return
+	}
+
+	scope := r.Scopes[len(r.Scopes)-1]
+	if _, exists := scope[name.Lexeme]; exists {
+		r.error(name, "Already a variable with this name in this scope.")
+	}
+	scope[name.Lexeme] = false
+}
+
+func (r *Resolver) define(name Token) {
+	if len(r.Scopes) == 0 {
+		return
+	}
+	r.Scopes[len(r.Scopes)-1][name.Lexeme] = true
+}
+
+func (r *Resolver) resolveLocal(id ExprID, name Token) {
+	for i := len(r.Scopes) - 1; i >= 0; i-- {
+		if _, exists := r.Scopes[i][name.Lexeme]; exists {
+			r.Locals[id] = len(r.Scopes) - 1 - i
+			return
+		}
+	}
+}
+
+func (r *Resolver) resolveFunction(function Function, functionType FunctionType) error {
+	enclosingFunction := r.CurrentFunction
+	r.CurrentFunction = functionType
+	enclosingLoops := r.LoopDepth
+	r.LoopDepth = 0
+
+	r.beginScope()
+	for _, param := range function.Params {
+		r.declare(param)
+		r.define(param)
+	}
+	err := r.resolve(function.Body)
+	r.endScope()
+
+	r.CurrentFunction = enclosingFunction
+	r.LoopDepth = enclosingLoops
+	return err
+}
+
+func (r *Resolver) VisitBlockStmt(stmt Block) error {
+	r.beginScope()
+	err := r.resolve(stmt.Statements)
+	r.endScope()
+	return err
+}
+
+func (r *Resolver) VisitVarStmt(stmt Var) error {
+	r.declare(stmt.Name)
+	if stmt.Initalizer != nil {
+		err := r.resolveExpr(*stmt.Initalizer)
+		if err != nil {
+			return err
+		}
+	}
+	r.define(stmt.Name)
+	return nil
+}
+
+func (r *Resolver) VisitFunctionStmt(stmt Function) error {
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	return r.resolveFunction(stmt, FUNCTION)
+}
+
+func (r *Resolver) VisitExpressionStmt(stmt Expression) error {
+	return r.resolveExpr(stmt.Expression)
+}
+
+func (r *Resolver) VisitIfStmt(stmt If) error {
+	err := r.resolveExpr(stmt.Condition)
+	if err != nil {
+		return err
+	}
+	err = r.resolveStmt(stmt.ThenBranch)
+	if err != nil {
+		return err
+	}
+	if stmt.ElseBranch != nil {
+		return r.resolveStmt(*stmt.ElseBranch)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitPrintStmt(stmt Print) error {
+	return r.resolveExpr(stmt.Expression)
+}
+
+func (r *Resolver) VisitReturnStmt(stmt Return) error {
+	if r.CurrentFunction == NONE_FUNCTION {
+		r.error(stmt.Keyword, "Can't return from top-level code.")
+	}
+
+	if stmt.Value != nil {
+		if r.CurrentFunction == INITIALIZER {
+			r.error(stmt.Keyword, "Can't return a value from an initializer.")
+		}
+		return r.resolveExpr(*stmt.Value)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitClassStmt(stmt Class) error {
+	enclosingClass := r.CurrentClass
+	r.CurrentClass = IN_CLASS
+
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+
+	if stmt.Superclass != nil {
+		if stmt.Name.Lexeme == stmt.Superclass.Name.Lexeme {
+			r.error(stmt.Superclass.Name, "A class can't inherit from itself.")
+		}
+
+		r.CurrentClass = IN_SUBCLASS
+		err := r.resolveExpr(*stmt.Superclass)
+		if err != nil {
+			r.CurrentClass = enclosingClass
+			return err
+		}
+
+		r.beginScope()
+		r.Scopes[len(r.Scopes)-1]["super"] = true
+	}
+
+	r.beginScope()
+	r.Scopes[len(r.Scopes)-1]["this"] = true
+
+	for _, method := range stmt.Methods {
+		declaration := METHOD
+		if method.Name.Lexeme == "init" {
+			declaration = INITIALIZER
+		}
+		err := r.resolveFunction(method, declaration)
+		if err != nil {
+			r.endScope()
+			if stmt.Superclass != nil {
+				r.endScope()
+			}
+			r.CurrentClass = enclosingClass
+			return err
+		}
+	}
+
+	r.endScope()
+	if stmt.Superclass != nil {
+		r.endScope()
+	}
+	r.CurrentClass = enclosingClass
+	return nil
+}
+
+func (r *Resolver) VisitWhileStmt(stmt While) error {
+	err := r.resolveExpr(stmt.Condition)
+	if err != nil {
+		return err
+	}
+
+	r.LoopDepth++
+	err = r.resolveStmt(stmt.Body)
+	r.LoopDepth--
+	if err != nil {
+		return err
+	}
+
+	if stmt.Increment != nil {
+		return r.resolveExpr(*stmt.Increment)
+	}
+	return nil
+}
+
+func (r *Resolver) VisitBreakStmt(stmt Break) error {
+	if r.LoopDepth == 0 {
+		r.error(stmt.Keyword, "Can't use 'break' outside of a loop.")
+	}
+	return nil
+}
+
+func (r *Resolver) VisitContinueStmt(stmt Continue) error {
+	if r.LoopDepth == 0 {
+		r.error(stmt.Keyword, "Can't use 'continue' outside of a loop.")
+	}
+	return nil
+}
+
+func (r *Resolver) VisitVariableExpr(expr Variable) (interface{}, error) {
+	if len(r.Scopes) != 0 {
+		if defined, exists := r.Scopes[len(r.Scopes)-1][expr.Name.Lexeme]; exists && !defined {
+			r.error(expr.Name, "Can't read local variable in its own initializer.")
+		}
+	}
+
+	r.resolveLocal(expr.ID, expr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitAssignExpr(expr Assign) (interface{}, error) {
+	err := r.resolveExpr(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	r.resolveLocal(expr.ID, expr.Name)
+	return nil, nil
+}
+
+func (r *Resolver) VisitBinaryExpr(expr Binary) (interface{}, error) {
+	err := r.resolveExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Right)
+}
+
+func (r *Resolver) VisitCallExpr(expr Call) (interface{}, error) {
+	err := r.resolveExpr(expr.Callee)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, argument := range expr.Arguments {
+		err = r.resolveExpr(argument)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitGroupingExpr(expr Grouping) (interface{}, error) {
+	return nil, r.resolveExpr(expr.Expression)
+}
+
+func (r *Resolver) VisitLiteralExpr(expr Literal) (interface{}, error) {
+	return nil, nil
+}
+
+func (r *Resolver) VisitLogicalExpr(expr Logical) (interface{}, error) {
+	err := r.resolveExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Right)
+}
+
+func (r *Resolver) VisitConditionalExpr(expr Conditional) (interface{}, error) {
+	err := r.resolveExpr(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+	err = r.resolveExpr(expr.ThenBranch)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.ElseBranch)
+}
+
+func (r *Resolver) VisitCoalesceExpr(expr Coalesce) (interface{}, error) {
+	err := r.resolveExpr(expr.Left)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Right)
+}
+
+func (r *Resolver) VisitCompoundAssignExpr(expr CompoundAssign) (interface{}, error) {
+	err := r.resolveExpr(expr.Target)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Value)
+}
+
+func (r *Resolver) VisitGetExpr(expr Get) (interface{}, error) {
+	return nil, r.resolveExpr(expr.Object)
+}
+
+func (r *Resolver) VisitSetExpr(expr Set) (interface{}, error) {
+	err := r.resolveExpr(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Object)
+}
+func (r *Resolver) VisitListExpr(expr List) (interface{}, error) {
+	for _, element := range expr.Elements {
+		err := r.resolveExpr(element)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+func (r *Resolver) VisitMapExpr(expr Map) (interface{}, error) {
+	for i := range expr.Keys {
+		err := r.resolveExpr(expr.Keys[i])
+		if err != nil {
+			return nil, err
+		}
+		err = r.resolveExpr(expr.Values[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+func (r *Resolver) VisitInterpolationExpr(expr Interpolation) (interface{}, error) {
+	for _, part := range expr.Parts {
+		err := r.resolveExpr(part)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitLambdaExpr(expr Lambda) (interface{}, error) {
+	return nil, r.resolveFunction(expr.Declaration, FUNCTION)
+}
+
+func (r *Resolver) VisitSubscriptExpr(expr Subscript) (interface{}, error) {
+	err := r.resolveExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Index)
+}
+func (r *Resolver) VisitSubscriptSetExpr(expr SubscriptSet) (interface{}, error) {
+	err := r.resolveExpr(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+	err = r.resolveExpr(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+	return nil, r.resolveExpr(expr.Value)
+}
+
+func (r *Resolver) VisitSuperExpr(expr Super) (interface{}, error) {
+	if r.CurrentClass == NONE_CLASS {
+		r.error(expr.Keyword, "Can't use 'super' outside of a class.")
+		return nil, nil
+	} else if r.CurrentClass != IN_SUBCLASS {
+		r.error(expr.Keyword, "Can't use 'super' in a class with no superclass.")
+		return nil, nil
+	}
+
+	r.resolveLocal(expr.ID, expr.Keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitThisExpr(expr This) (interface{}, error) {
+	if r.CurrentClass == NONE_CLASS {
+		r.error(expr.Keyword, "Can't use 'this' outside of a class.")
+		return nil, nil
+	}
+
+	r.resolveLocal(expr.ID, expr.Keyword)
+	return nil, nil
+}
+
+func (r *Resolver) VisitUnaryExpr(expr Unary) (interface{}, error) {
+	return nil, r.resolveExpr(expr.Right)
+}
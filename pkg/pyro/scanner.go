@@ -0,0 +1,675 @@
+package pyro
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Scanner turns source text into tokens on demand through NextToken. Start
+// and Current are byte offsets into Source; characters are decoded from UTF-8
+// one at a time as the scanner moves, so offsets and spans always agree.
+type Scanner struct {
+	File        *SourceFile
+	Source      string
+	Start       int
+	Current     int
+	Line        int
+	Column      int // characters consumed on the current line
+	StartLine   int
+	StartColumn int
+	// Unterminated is set when the source ends inside a string literal.
+	Unterminated bool
+	Keywords     map[string]TokenType
+	Diagnostics  *Diagnostics
+
+	// pending holds tokens scanned but not yet returned. Most calls to
+	// scanToken add one token or none, for whitespace and comments, but an
+	// interpolated string adds its segments and embedded tokens together.
+	pending []Token
+}
+
+func NewScanner(file *SourceFile, diagnostics *Diagnostics) *Scanner {
+	var keywords = map[string]TokenType{
+		"and":      AND,
+		"break":    BREAK,
+		"class":    CLASS,
+		"continue": CONTINUE,
+		"else":     ELSE,
+		"false":    FALSE,
+		"for":      FOR,
+		"fun":      FUN,
+		"if":       IF,
+		"nil":      NIL,
+		"or":       OR,
+		"print":    PRINT,
+		"return":   RETURN,
+		"super":    SUPER,
+		"this":     THIS,
+		"true":     TRUE,
+		"var":      VAR,
+		"while":    WHILE,
+	}
+
+	return &Scanner{
+		File:        file,
+		Source:      file.Text,
+		Start:       0,
+		Current:     0,
+		Line:        1,
+		Column:      0,
+		Keywords:    keywords,
+		Diagnostics: diagnostics,
+	}
+}
+
+// NextToken scans and returns the next token, skipping whitespace and
+// comments. Once the source is exhausted it returns EOF on every call.
+func (s *Scanner) NextToken() Token {
+	for len(s.pending) == 0 && !s.isAtEnd() {
+		s.startToken()
+		s.scanToken()
+	}
+	if len(s.pending) > 0 {
+		token := s.pending[0]
+		// Shift rather than reslice, so the buffer keeps its capacity and
+		// scanning does not allocate a new one for every token.
+		s.pending = s.pending[:copy(s.pending, s.pending[1:])]
+		return token
+	}
+	s.startToken()
+	return NewToken(EOF, "", s.span())
+}
+
+// startToken marks the current position as the beginning of the next token.
+func (s *Scanner) startToken() {
+	s.Start = s.Current
+	s.StartLine = s.Line
+	s.StartColumn = s.Column + 1
+}
+
+// span covers the source consumed since the last startToken.
+func (s *Scanner) span() Span {
+	return Span{
+		File:   s.File,
+		Start:  s.Start,
+		End:    s.Current,
+		Line:   s.StartLine,
+		Column: s.StartColumn,
+	}
+}
+
+func (s *Scanner) isAtEnd() bool {
+	return s.Current >= len(s.Source)
+}
+
+func (s *Scanner) scanToken() {
+	c := s.advance()
+
+	switch c {
+	case '(':
+		s.addToken(LPAREN)
+	case ')':
+		s.addToken(RPAREN)
+	case '{':
+		s.addToken(LBRACE)
+	case '}':
+		s.addToken(RBRACE)
+	case '[':
+		s.addToken(LBRACKET)
+	case ']':
+		s.addToken(RBRACKET)
+	case ',':
+		s.addToken(COMMA)
+	case ':':
+		s.addToken(COLON)
+	case '.':
+		s.addToken(DOT)
+	case '-':
+		tt := MINUS
+		if s.match('-') {
+			tt = MINUSMINUS
+		} else if s.match('=') {
+			tt = MINUSEQ
+		}
+		s.addToken(tt)
+	case '+':
+		tt := PLUS
+		if s.match('+') {
+			tt = PLUSPLUS
+		} else if s.match('=') {
+			tt = PLUSEQ
+		}
+		s.addToken(tt)
+	case ';':
+		s.addToken(SEMICOLON)
+	case '*':
+		tt := STAR
+		if s.match('*') {
+			tt = STARSTAR
+		} else if s.match('=') {
+			tt = STAREQ
+		}
+		s.addToken(tt)
+	case '&':
+		s.addToken(AMP)
+	case '|':
+		s.addToken(PIPE)
+	case '^':
+		s.addToken(CARET)
+	case '~':
+		s.addToken(TILDE)
+	case '?':
+		tt := QUESTION
+		if s.match('?') {
+			tt = QUESTIONQUESTION
+		}
+		s.addToken(tt)
+	case '!':
+		tt := NOT
+		if s.match('=') {
+			tt = NE
+		}
+		s.addToken(tt)
+	case '/':
+		tt := SLASH
+		if s.match('/') {
+			tt = SLASHSLASH
+		} else if s.match('=') {
+			tt = SLASHEQ
+		}
+		s.addToken(tt)
+	case '%':
+		tt := MOD
+		if s.match('=') {
+			tt = MODEQ
+		}
+		s.addToken(tt)
+	case '=':
+		tt := EQ
+		if s.match('=') {
+			tt = EQEQ
+		} else if s.match('>') {
+			tt = ARROW
+		}
+		s.addToken(tt)
+	case '>':
+		tt := GT
+		if s.match('=') {
+			tt = GE
+		} else if s.match('>') {
+			tt = GTGT
+		}
+		s.addToken(tt)
+	case '<':
+		tt := LT
+		if s.match('=') {
+			tt = LE
+		} else if s.match('<') {
+			tt = LTLT
+		}
+		s.addToken(tt)
+	case '#':
+		for s.peek() != '\n' && !s.isAtEnd() {
+			s.advance()
+		}
+	case '"':
+		s.scanString(false)
+	case ' ':
+	case '\r':
+	case '\t':
+	case '\n':
+		s.newLine()
+	default:
+		if isDigit(c) {
+			s.scanNum()
+		} else if c == 'r' && s.peek() == '"' {
+			s.advance()
+			s.scanString(true)
+		} else if isAlpha(c) {
+			s.scanIdentifier()
+		} else {
+			s.error("Unexpected character.")
+		}
+	}
+}
+
+func (s *Scanner) newLine() {
+	s.Line++
+	s.Column = 0
+}
+
+func (s *Scanner) error(message string) {
+	s.errorAt(s.span(), message)
+}
+
+func (s *Scanner) errorAt(span Span, message string) {
+	err := NewError(span.Line, message, "")
+	err.Kind = SCAN_ERROR
+	err.Span = span
+	err.Column = err.Span.Column
+	s.Diagnostics.report(err)
+}
+
+func (s *Scanner) scanIdentifier() {
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+
+	text := s.Source[s.Start:s.Current]
+	tt, exists := s.Keywords[text]
+	if !exists {
+		tt = ID
+	}
+	s.addToken(tt)
+
+}
+
+func isAlpha(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c == '_')
+}
+
+func isAlphaNumeric(c rune) bool {
+	return (isDigit(c) || isAlpha(c))
+}
+
+// stringLine is one source line of a string literal: the whitespace it
+// starts with, kept apart so triple-quoted strings can strip it, and the rest
+// of the line with escapes decoded. cuts are the offsets in text where ${...}
+// interpolations were taken out.
+type stringLine struct {
+	indent string
+	text   strings.Builder
+	cuts   []int
+}
+
+func (sl *stringLine) blank() bool {
+	return sl.text.Len() == 0 && len(sl.cuts) == 0
+}
+
+// scanString scans a string literal after its opening quote, decoding escape
+// sequences unless raw is set. Three quotes open a multi-line string that
+// runs to the next three quotes; see dedent for how its lines are trimmed.
+//
+// A string containing ${...} becomes an INTERPOLATION token for the text
+// before each embedded expression, followed by that expression's tokens and
+// its closing '}', and a final STRING token for the text after the last one.
+func (s *Scanner) scanString(raw bool) {
+	triple := s.peek() == '"' && s.peekNext() == '"'
+	if triple {
+		s.advance()
+		s.advance()
+	}
+
+	first := len(s.pending)
+	segment := s.span()
+	segments := make([]Span, 0)
+	placeholders := make([]int, 0)
+	lines := []*stringLine{{}}
+	open := segment
+	for !s.isAtEnd() && !s.atClosingQuote(triple) {
+		line := lines[len(lines)-1]
+		c := s.advance()
+		switch {
+		case c == '\n':
+			s.newLine()
+			if triple {
+				lines = append(lines, &stringLine{})
+			} else {
+				line.text.WriteRune(c)
+			}
+		case triple && c == '\r' && s.peek() == '\n':
+		case triple && line.blank() && (c == ' ' || c == '\t'):
+			line.indent += string(c)
+		case c == '\\' && !raw:
+			s.escape(&line.text)
+		case c == '$' && s.peek() == '{' && !raw:
+			s.advance()
+			segment.End = s.Current
+			segments = append(segments, segment)
+			line.cuts = append(line.cuts, line.text.Len())
+			placeholders = append(placeholders, len(s.pending))
+			s.pending = append(s.pending, Token{}) // filled in once the string ends
+
+			s.scanInterpolation()
+			segment = Span{File: s.File, Start: s.Current, Line: s.Line, Column: s.Column + 1}
+		default:
+			line.text.WriteRune(c)
+		}
+	}
+
+	if s.isAtEnd() {
+		s.pending = s.pending[:first]
+		// A string left open inside an interpolation has already been
+		// reported; the one around it is unterminated only because of it.
+		if !s.Unterminated {
+			s.Unterminated = true
+			open.End = s.Current
+			s.errorAt(open, "Unterminated string.")
+		}
+		return
+	}
+
+	s.advance() //closing "
+	if triple {
+		s.advance()
+		s.advance()
+	}
+
+	values := dedent(lines)
+	for i, index := range placeholders {
+		s.pending[index] = NewToken(INTERPOLATION, values[i], segments[i])
+	}
+	s.Start, s.StartLine, s.StartColumn = segment.Start, segment.Line, segment.Column
+	s.addTokenString(values[len(values)-1])
+}
+
+// scanInterpolation scans the tokens of an embedded expression after its
+// "${", up to and including the '}' that closes it.
+func (s *Scanner) scanInterpolation() {
+	depth := 0
+	for !s.isAtEnd() {
+		switch s.peek() {
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				s.startToken()
+				s.advance()
+				s.addToken(RBRACE)
+				return
+			}
+			depth--
+		}
+		s.startToken()
+		s.scanToken()
+	}
+}
+
+func (s *Scanner) atClosingQuote(triple bool) bool {
+	if triple {
+		return strings.HasPrefix(s.Source[s.Current:], `"""`)
+	}
+	return s.peek() == '"'
+}
+
+// escape decodes the escape sequence following a backslash into text.
+// Unknown and malformed sequences are reported at the sequence itself.
+func (s *Scanner) escape(text *strings.Builder) {
+	start := Span{File: s.File, Start: s.Current - 1, Line: s.Line, Column: s.Column}
+	if s.isAtEnd() {
+		return // reported as an unterminated string
+	}
+
+	switch c := s.peek(); c {
+	case 'n':
+		text.WriteByte('\n')
+	case 't':
+		text.WriteByte('\t')
+	case 'r':
+		text.WriteByte('\r')
+	case '0':
+		text.WriteByte(0)
+	case '"', '\\', '$':
+		text.WriteRune(c)
+	case 'u':
+		s.advance()
+		s.unicodeEscape(text, start)
+		return
+	default:
+		if c != '\n' {
+			s.advance()
+		}
+		start.End = s.Current
+		s.errorAt(start, "Invalid escape sequence '"+s.Source[start.Start:start.End]+"'.")
+		return
+	}
+	s.advance()
+}
+
+// unicodeEscape decodes the rest of a \u{...} escape, which names a code
+// point with one to six hex digits.
+func (s *Scanner) unicodeEscape(text *strings.Builder, start Span) {
+	if !s.match('{') {
+		start.End = s.Current
+		s.errorAt(start, "Expect '{' after '\\u' in a Unicode escape.")
+		return
+	}
+	digits := s.Current
+	for isHexDigit(s.peek()) {
+		s.advance()
+	}
+	hex := s.Source[digits:s.Current]
+	closed := s.match('}')
+	start.End = s.Current
+
+	if !closed || len(hex) == 0 || len(hex) > 6 {
+		s.errorAt(start, "Unicode escape must be 1 to 6 hex digits in braces, like \\u{1F600}.")
+		return
+	}
+	code, _ := strconv.ParseUint(hex, 16, 32)
+	if !utf8.ValidRune(rune(code)) {
+		s.errorAt(start, "Invalid Unicode code point '"+s.Source[start.Start:start.End]+"'.")
+		return
+	}
+	text.WriteRune(rune(code))
+}
+
+// dedent joins the lines of a string literal and splits the result at its
+// interpolations, giving the text of each segment.
+//
+// Only triple-quoted strings have more than one line. Text on the line of
+// the opening quotes is kept as written, and dropped if blank. The
+// indentation shared by every later line is removed, including the line
+// holding the closing quotes, which is dropped when nothing precedes the
+// quotes on it. That line therefore decides how much indentation is stripped.
+func dedent(lines []*stringLine) []string {
+	var out strings.Builder
+	cuts := make([]int, 0)
+	write := func(line *stringLine, indent string) {
+		out.WriteString(indent)
+		for _, cut := range line.cuts {
+			cuts = append(cuts, out.Len()+cut)
+		}
+		out.WriteString(line.text.String())
+	}
+
+	first, rest := lines[0], lines[1:]
+	keepFirst := len(rest) == 0 || !first.blank()
+	if keepFirst {
+		write(first, first.indent)
+	}
+	if len(rest) > 0 {
+		common := rest[len(rest)-1].indent
+		if rest[len(rest)-1].blank() {
+			rest = rest[:len(rest)-1]
+		}
+		for _, line := range rest {
+			if !line.blank() {
+				common = commonPrefix(common, line.indent)
+			}
+		}
+		for i, line := range rest {
+			if i > 0 || keepFirst {
+				out.WriteByte('\n')
+			}
+			if !line.blank() {
+				write(line, line.indent[len(common):])
+			}
+		}
+	}
+
+	text := out.String()
+	segments := make([]string, 0, len(cuts)+1)
+	start := 0
+	for _, cut := range cuts {
+		segments = append(segments, text[start:cut])
+		start = cut
+	}
+	return append(segments, text[start:])
+}
+
+func commonPrefix(a string, b string) string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
+
+// scanNum scans a decimal integer or float, or an integer in hex, binary or
+// octal after a 0x, 0b or 0o prefix. Underscores may separate digits.
+func (s *Scanner) scanNum() {
+	if s.Source[s.Start] == '0' && strings.ContainsRune("xXbBoO", s.peek()) {
+		s.scanPrefixedNum()
+		return
+	}
+
+	ok := s.digits()
+	if s.peek() == '.' && isDigit(s.peekNext()) {
+		s.advance()
+		ok = s.digits() && ok
+	}
+	if s.peek() == 'd' && !isAlphaNumeric(s.peekNext()) {
+		s.advance()
+	}
+	if !ok {
+		s.addInvalidNum()
+		return
+	}
+	s.addToken(NUM)
+}
+
+// digits consumes decimal digits and any underscores among them, and
+// reports false if an underscore is not between two digits.
+func (s *Scanner) digits() bool {
+	start := s.Current
+	for isDigit(s.peek()) || s.peek() == '_' {
+		s.advance()
+	}
+	if run := s.Source[start:s.Current]; strings.HasSuffix(run, "_") || strings.Contains(run, "__") {
+		s.error("Underscores in a number must be between digits.")
+		return false
+	}
+	return true
+}
+
+// scanPrefixedNum scans the rest of a literal such as 0xFF. Everything up to
+// the next character that can't continue a name is taken as its digits, so
+// 0b102 is reported as one bad literal rather than two tokens.
+func (s *Scanner) scanPrefixedNum() {
+	prefix := s.advance()
+	for isAlphaNumeric(s.peek()) {
+		s.advance()
+	}
+
+	base, name := 16, "hex"
+	switch prefix {
+	case 'b', 'B':
+		base, name = 2, "binary"
+	case 'o', 'O':
+		base, name = 8, "octal"
+	}
+
+	digits := s.Source[s.Start+2 : s.Current]
+	switch {
+	case digits == "":
+		s.error("Expect digits after '0" + string(prefix) + "'.")
+		s.addInvalidNum()
+		return
+	case digits[0] == '_' || digits[len(digits)-1] == '_' || strings.Contains(digits, "__"):
+		s.error("Underscores in a number must be between digits.")
+		s.addInvalidNum()
+		return
+	}
+	for _, c := range digits {
+		if c != '_' && !isDigitIn(c, base) {
+			s.error("Invalid digit '" + string(c) + "' in " + name + " literal.")
+			s.addInvalidNum()
+			return
+		}
+	}
+	s.addToken(NUM)
+}
+
+// addInvalidNum stands in for a number literal that has been reported as
+// malformed. The token spans the literal but reads as 0, so the parser
+// carries on without reporting the same mistake again.
+func (s *Scanner) addInvalidNum() {
+	s.pending = append(s.pending, NewToken(NUM, "0", s.span()))
+}
+
+func isDigitIn(c rune, base int) bool {
+	switch base {
+	case 2:
+		return c == '0' || c == '1'
+	case 8:
+		return c >= '0' && c <= '7'
+	}
+	return isHexDigit(c)
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+// peek returns the next character without consuming it, or 0 at the end.
+func (s *Scanner) peek() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	return s.decode(s.Current)
+}
+
+func (s *Scanner) peekNext() rune {
+	if s.isAtEnd() {
+		return 0
+	}
+	_, width := utf8.DecodeRuneInString(s.Source[s.Current:])
+	if s.Current+width >= len(s.Source) {
+		return 0
+	}
+	return s.decode(s.Current + width)
+}
+
+func (s *Scanner) match(c rune) bool {
+	if s.isAtEnd() || s.peek() != c {
+		return false
+	}
+	s.advance()
+	return true
+}
+
+func (s *Scanner) advance() rune {
+	c, width := utf8.DecodeRuneInString(s.Source[s.Current:])
+	s.Current += width
+	s.Column++
+	return c
+}
+
+// decode returns the character starting at byte offset, taking the ASCII
+// fast path when it can.
+func (s *Scanner) decode(offset int) rune {
+	if b := s.Source[offset]; b < utf8.RuneSelf {
+		return rune(b)
+	}
+	c, _ := utf8.DecodeRuneInString(s.Source[offset:])
+	return c
+}
+
+func (s *Scanner) addToken(tt TokenType) {
+	s.addTokenScanner(tt)
+}
+
+func (s *Scanner) addTokenScanner(tt TokenType) {
+	value := s.Source[s.Start:s.Current]
+	s.pending = append(s.pending, NewToken(tt, value, s.span()))
+}
+
+func (s *Scanner) addTokenString(value string) {
+	s.pending = append(s.pending, NewToken(STRING, value, s.span()))
+}
@@ -0,0 +1,148 @@
+package pyro
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func scanAll(source string) ([]Token, *Diagnostics) {
+	diagnostics := NewDiagnostics(nil)
+	scanner := NewScanner(NewSourceFile("test.pyro", source), diagnostics)
+	tokens := make([]Token, 0)
+	for {
+		token := scanner.NextToken()
+		tokens = append(tokens, token)
+		if token.Type == EOF {
+			return tokens, diagnostics
+		}
+	}
+}
+
+// Non-ASCII text inside a string must not throw off the lexeme of the string
+// or the position of anything after it on the line.
+func TestScannerNonASCIIStrings(t *testing.T) {
+	source := "var greeting = \"héllo, wörld 😀\"; print greeting;\n" +
+		"print \"日本語\" + \"ok\";"
+	want := []struct {
+		tt     TokenType
+		lexeme string
+		text   string
+		line   int
+		column int
+	}{
+		{VAR, "var", "var", 1, 1},
+		{ID, "greeting", "greeting", 1, 5},
+		{EQ, "=", "=", 1, 14},
+		{STRING, "héllo, wörld 😀", `"héllo, wörld 😀"`, 1, 16},
+		{SEMICOLON, ";", ";", 1, 32},
+		{PRINT, "print", "print", 1, 34},
+		{ID, "greeting", "greeting", 1, 40},
+		{SEMICOLON, ";", ";", 1, 48},
+		{PRINT, "print", "print", 2, 1},
+		{STRING, "日本語", `"日本語"`, 2, 7},
+		{PLUS, "+", "+", 2, 13},
+		{STRING, "ok", `"ok"`, 2, 15},
+		{SEMICOLON, ";", ";", 2, 19},
+		{EOF, "", "", 2, 20},
+	}
+
+	tokens, diagnostics := scanAll(source)
+	if diagnostics.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diagnostics.Errors)
+	}
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, token := range tokens {
+		w := want[i]
+		text := source[token.Span.Start:token.Span.End]
+		if token.Type != w.tt || token.Lexeme != w.lexeme || text != w.text || token.Span.Line != w.line || token.Span.Column != w.column {
+			t.Errorf("token %d: got %v %q spanning %q at %d:%d, want %v %q spanning %q at %d:%d",
+				i, token.Type, token.Lexeme, text, token.Span.Line, token.Span.Column,
+				w.tt, w.lexeme, w.text, w.line, w.column)
+		}
+	}
+}
+
+// A malformed number literal is reported once and still scans as a single
+// NUM, so the parser does not go on to report a missing expression.
+func TestScannerMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"0x;", "Expect digits after '0x'."},
+		{"0b102;", "Invalid digit '2' in binary literal."},
+		{"0o9;", "Invalid digit '9' in octal literal."},
+		{"0xZ;", "Invalid digit 'Z' in hex literal."},
+		{"0x_F;", "Underscores in a number must be between digits."},
+		{"1__0;", "Underscores in a number must be between digits."},
+		{"1_;", "Underscores in a number must be between digits."},
+		{"1.5__5;", "Underscores in a number must be between digits."},
+		{"2.5_d;", "Underscores in a number must be between digits."},
+	}
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			tokens, diagnostics := scanAll(test.source)
+			if len(diagnostics.Errors) != 1 || diagnostics.Errors[0].Message != test.want {
+				t.Fatalf("got errors %v, want just %q", diagnostics.Errors, test.want)
+			}
+			literal := test.source[:len(test.source)-1]
+			if len(tokens) != 3 || tokens[0].Type != NUM || tokens[0].Span.End-tokens[0].Span.Start != len(literal) || tokens[1].Type != SEMICOLON {
+				t.Errorf("got tokens %v, want one NUM spanning %q then ';'", tokens, literal)
+			}
+		})
+	}
+
+	for _, source := range []string{"1_000;", "0xFF_FF;", "0b1_0;", "1_0.2_5d;"} {
+		if _, diagnostics := scanAll(source); diagnostics.HasErrors() {
+			t.Errorf("%q: unexpected errors %v", source, diagnostics.Errors)
+		}
+	}
+}
+
+// generatedSource builds a program of at least size bytes in the style of a
+// generated data script, with non-ASCII text in most string literals.
+func generatedSource(size int) string {
+	var source strings.Builder
+	for i := 0; source.Len() < size; i++ {
+		n := strconv.Itoa(i)
+		source.WriteString("var item" + n + ` = {"name": "Gerät №` + n + ` — café ☕", "price": ` + n + `.5, "tags": ["größe", "naïve", "日本"]};` + "\n")
+		source.WriteString("if (item" + n + `["price"] > 100) print "expensive: ${item` + n + `["name"]}";` + "\n")
+	}
+	return source.String()
+}
+
+// drain scans source without keeping the tokens.
+func drain(source string) *Diagnostics {
+	diagnostics := NewDiagnostics(nil)
+	scanner := NewScanner(NewSourceFile("bench.pyro", source), diagnostics)
+	for scanner.NextToken().Type != EOF {
+	}
+	return diagnostics
+}
+
+// BenchmarkScanner tokenizes sources of growing size. Scanning is linear, so
+// the MB/s figure should stay roughly constant from one size to the next.
+func BenchmarkScanner(b *testing.B) {
+	for _, size := range []struct {
+		name  string
+		bytes int
+	}{
+		{"64KB", 64 << 10},
+		{"1MB", 1 << 20},
+		{"8MB", 8 << 20},
+	} {
+		source := generatedSource(size.bytes)
+		if diagnostics := drain(source); diagnostics.HasErrors() {
+			b.Fatalf("generated source has errors: %v", diagnostics.Errors[0])
+		}
+		b.Run(size.name, func(b *testing.B) {
+			b.SetBytes(int64(len(source)))
+			for i := 0; i < b.N; i++ {
+				drain(source)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package pyro
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// SourceFile is a named piece of program text that spans point into.
+type SourceFile struct {
+	Name string
+	Text string
+}
+
+func NewSourceFile(name string, text string) *SourceFile {
+	return &SourceFile{
+		Name: name,
+		Text: text,
+	}
+}
+
+// lineText returns the text of the 1-based line, without its newline.
+func (sf *SourceFile) lineText(line int) string {
+	lines := strings.Split(sf.Text, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line-1], "\r")
+}
+
+// Span is a half-open byte range [Start, End) of a SourceFile. Line and
+// Column (both 1-based, Column counted in characters) locate Start.
+type Span struct {
+	File   *SourceFile
+	Start  int
+	End    int
+	Line   int
+	Column int
+}
+
+// To returns a span running from the start of s to the end of other.
+func (s Span) To(other Span) Span {
+	if other.End < s.End {
+		return s
+	}
+	s.End = other.End
+	return s
+}
+
+// width is the number of characters the span covers on its first line.
+func (s Span) width() int {
+	if s.File == nil || s.End <= s.Start || s.End > len(s.File.Text) {
+		return 1
+	}
+	text := s.File.Text[s.Start:s.End]
+	if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+		text = text[:newline]
+	}
+	if width := utf8.RuneCountInString(text); width > 0 {
+		return width
+	}
+	return 1
+}
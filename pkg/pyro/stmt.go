@@ -0,0 +1,263 @@
+package pyro
+
+type Stmt interface {
+	Accept(visitor StmtVisitor) error
+	Span() Span
+}
+
+type StmtVisitor interface {
+	VisitVarStmt(stmt Var) error
+	VisitPrintStmt(stmt Print) error
+	VisitExpressionStmt(stmt Expression) error
+	VisitBlockStmt(stmt Block) error
+	VisitIfStmt(stmt If) error
+	VisitWhileStmt(stmt While) error
+	VisitFunctionStmt(stmt Function) error
+	VisitReturnStmt(stmt Return) error
+	VisitClassStmt(stmt Class) error
+	VisitBreakStmt(stmt Break) error
+	VisitContinueStmt(stmt Continue) error
+}
+
+type Class struct {
+	Name       Token
+	Superclass *Variable
+	Methods    []Function
+	Location   Span
+}
+
+func NewClass(name Token, superclass *Variable, methods []Function, span Span) Class {
+	return Class{
+		Name:       name,
+		Superclass: superclass,
+		Methods:    methods,
+		Location:   span,
+	}
+}
+
+func (c Class) Accept(visitor StmtVisitor) error {
+	return visitor.VisitClassStmt(c)
+}
+
+func (c Class) Span() Span {
+	return c.Location
+}
+
+type Function struct {
+	Name     Token
+	Params   []Token
+	Body     []Stmt
+	Location Span
+}
+
+func NewFunction(name Token, params []Token, body []Stmt, span Span) Function {
+	return Function{
+		Name:     name,
+		Params:   params,
+		Body:     body,
+		Location: span,
+	}
+}
+
+func (f Function) Accept(visitor StmtVisitor) error {
+	return visitor.VisitFunctionStmt(f)
+}
+
+func (f Function) Span() Span {
+	return f.Location
+}
+
+// While runs Body for as long as Condition holds. Increment, when set, runs
+// after every iteration including one cut short by continue; for loops are
+// desugared into it.
+type While struct {
+	Condition Expr
+	Body      Stmt
+	Increment *Expr
+	Location  Span
+}
+
+func NewWhile(condition Expr, body Stmt, increment *Expr, span Span) While {
+	return While{
+		Condition: condition,
+		Body:      body,
+		Increment: increment,
+		Location:  span,
+	}
+}
+
+func (w While) Accept(visitor StmtVisitor) error {
+	return visitor.VisitWhileStmt(w)
+}
+
+func (w While) Span() Span {
+	return w.Location
+}
+
+type If struct {
+	Condition  Expr
+	ThenBranch Stmt
+	ElseBranch *Stmt
+	Location   Span
+}
+
+func NewIf(condition Expr, thenBranch Stmt, elseBranch *Stmt, span Span) If {
+	return If{
+		Condition:  condition,
+		ThenBranch: thenBranch,
+		ElseBranch: elseBranch,
+		Location:   span,
+	}
+}
+
+func (i If) Accept(visitor StmtVisitor) error {
+	return visitor.VisitIfStmt(i)
+}
+
+func (i If) Span() Span {
+	return i.Location
+}
+
+type Block struct {
+	Statements []Stmt
+	Location   Span
+}
+
+func NewBlock(statements []Stmt, span Span) Block {
+	return Block{
+		Statements: statements,
+		Location:   span,
+	}
+}
+
+func (b Block) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBlockStmt(b)
+}
+
+func (b Block) Span() Span {
+	return b.Location
+}
+
+type Var struct {
+	Name       Token
+	Initalizer *Expr
+	Location   Span
+}
+
+func (v Var) Accept(visitor StmtVisitor) error {
+	return visitor.VisitVarStmt(v)
+}
+
+func (v Var) Span() Span {
+	return v.Location
+}
+
+func NewVar(name Token, intializer *Expr, span Span) Var {
+	return Var{
+		Name:       name,
+		Initalizer: intializer,
+		Location:   span,
+	}
+}
+
+type Print struct {
+	Expression Expr
+	Location   Span
+}
+
+func (p Print) Accept(visitor StmtVisitor) error {
+	return visitor.VisitPrintStmt(p)
+}
+
+func (p Print) Span() Span {
+	return p.Location
+}
+
+func NewPrint(expr Expr, span Span) Print {
+	return Print{
+		Expression: expr,
+		Location:   span,
+	}
+}
+
+type Expression struct {
+	Expression Expr
+	Location   Span
+}
+
+func (e Expression) Accept(visitor StmtVisitor) error {
+	return visitor.VisitExpressionStmt(e)
+}
+
+func (e Expression) Span() Span {
+	return e.Location
+}
+
+func NewExpression(expr Expr, span Span) Expression {
+	return Expression{
+		Expression: expr,
+		Location:   span,
+	}
+}
+
+type Return struct {
+	Keyword  Token
+	Value    *Expr
+	Location Span
+}
+
+func (r Return) Accept(visitor StmtVisitor) error {
+	return visitor.VisitReturnStmt(r)
+}
+
+func (r Return) Span() Span {
+	return r.Location
+}
+
+func NewReturn(keyword Token, value *Expr, span Span) Return {
+	return Return{
+		Keyword:  keyword,
+		Value:    value,
+		Location: span,
+	}
+}
+
+type Break struct {
+	Keyword  Token
+	Location Span
+}
+
+func (b Break) Accept(visitor StmtVisitor) error {
+	return visitor.VisitBreakStmt(b)
+}
+
+func (b Break) Span() Span {
+	return b.Location
+}
+
+func NewBreak(keyword Token, span Span) Break {
+	return Break{
+		Keyword:  keyword,
+		Location: span,
+	}
+}
+
+type Continue struct {
+	Keyword  Token
+	Location Span
+}
+
+func (c Continue) Accept(visitor StmtVisitor) error {
+	return visitor.VisitContinueStmt(c)
+}
+
+func (c Continue) Span() Span {
+	return c.Location
+}
+
+func NewContinue(keyword Token, span Span) Continue {
+	return Continue{
+		Keyword:  keyword,
+		Location: span,
+	}
+}
@@ -1,4 +1,4 @@
-package main
+package pyro
 
 import "fmt"
 
@@ -9,13 +9,18 @@ const (
 	ID TokenType = iota
 	STRING
 	NUM
+	// INTERPOLATION is the text of a string before an embedded ${...}.
+	INTERPOLATION
 
 	// Single Character Tokens
 	LPAREN
 	RPAREN
 	LBRACE
 	RBRACE
+	LBRACKET
+	RBRACKET
 	COMMA
+	COLON
 	DOT
 	MINUS
 	PLUS
@@ -24,6 +29,11 @@ const (
 	MOD
 	SLASH
 	HASHTAG
+	AMP
+	PIPE
+	CARET
+	TILDE
+	QUESTION
 
 	//One or two character tokens
 	NOT
@@ -34,6 +44,19 @@ const (
 	GE
 	LT
 	LE
+	ARROW
+	STARSTAR
+	SLASHSLASH
+	LTLT
+	GTGT
+	QUESTIONQUESTION
+	PLUSEQ
+	MINUSEQ
+	STAREQ
+	SLASHEQ
+	MODEQ
+	PLUSPLUS
+	MINUSMINUS
 
 	//Keyword
 	AND
@@ -53,6 +76,8 @@ const (
 	THIS
 	SUPER
 	VAR
+	BREAK
+	CONTINUE
 
 	EOF
 )
@@ -61,13 +86,15 @@ type Token struct {
 	Type   TokenType
 	Lexeme string
 	Line   int
+	Span   Span
 }
 
-func NewToken(tt TokenType, lexeme string, line int) Token {
+func NewToken(tt TokenType, lexeme string, span Span) Token {
 	return Token{
 		Type:   tt,
 		Lexeme: lexeme,
-		Line:   line,
+		Line:   span.Line,
+		Span:   span,
 	}
 }
 
@@ -83,6 +110,8 @@ func (t TokenType) String() string {
 		return "STRING"
 	case NUM:
 		return "NUM"
+	case INTERPOLATION:
+		return "INTERPOLATION"
 	case LPAREN:
 		return "LPAREN"
 	case RPAREN:
@@ -91,8 +120,14 @@ func (t TokenType) String() string {
 		return "LBRACE"
 	case RBRACE:
 		return "RBRACE"
+	case LBRACKET:
+		return "LBRACKET"
+	case RBRACKET:
+		return "RBRACKET"
 	case COMMA:
 		return "COMMA"
+	case COLON:
+		return "COLON"
 	case DOT:
 		return "DOT"
 	case MINUS:
@@ -109,6 +144,16 @@ func (t TokenType) String() string {
 		return "SLASH"
 	case HASHTAG:
 		return "HASHTAG"
+	case AMP:
+		return "AMP"
+	case PIPE:
+		return "PIPE"
+	case CARET:
+		return "CARET"
+	case TILDE:
+		return "TILDE"
+	case QUESTION:
+		return "QUESTION"
 	case NOT:
 		return "NOT"
 	case NE:
@@ -125,6 +170,32 @@ func (t TokenType) String() string {
 		return "LT"
 	case LE:
 		return "LE"
+	case ARROW:
+		return "ARROW"
+	case STARSTAR:
+		return "STARSTAR"
+	case SLASHSLASH:
+		return "SLASHSLASH"
+	case LTLT:
+		return "LTLT"
+	case GTGT:
+		return "GTGT"
+	case QUESTIONQUESTION:
+		return "QUESTIONQUESTION"
+	case PLUSEQ:
+		return "PLUSEQ"
+	case MINUSEQ:
+		return "MINUSEQ"
+	case STAREQ:
+		return "STAREQ"
+	case SLASHEQ:
+		return "SLASHEQ"
+	case MODEQ:
+		return "MODEQ"
+	case PLUSPLUS:
+		return "PLUSPLUS"
+	case MINUSMINUS:
+		return "MINUSMINUS"
 	case AND:
 		return "AND"
 	case ELSE:
@@ -159,6 +230,10 @@ func (t TokenType) String() string {
 		return "SUPER"
 	case VAR:
 		return "VAR"
+	case BREAK:
+		return "BREAK"
+	case CONTINUE:
+		return "CONTINUE"
 	case EOF:
 		return "EOF"
 	default:
@@ -0,0 +1,803 @@
+package pyro
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxFrames bounds how deeply calls may nest, counting the script's own
+// frame. The tree-walker applies the same limit, so runaway recursion is a
+// runtime error on both backends rather than a crash of the host.
+const maxFrames = 4096
+
+type CallFrame struct {
+	Closure *Closure
+	IP      int
+	Base    int
+}
+
+// vmValue is a VM stack entry. Floats and int64 integers are held unboxed in
+// num so arithmetic does not allocate: an int is num itself and a float is
+// num's bits. kind says which; every other value, including *big.Int, lives
+// in obj.
+type vmValue struct {
+	obj  interface{}
+	num  int64
+	kind vmKind
+}
+
+type vmKind uint8
+
+const (
+	vmObj vmKind = iota
+	vmFloat
+	vmInt
+)
+
+func floatVM(f float64) vmValue {
+	return vmValue{num: int64(math.Float64bits(f)), kind: vmFloat}
+}
+
+func intVM(n int64) vmValue {
+	return vmValue{num: n, kind: vmInt}
+}
+
+func toVM(value interface{}) vmValue {
+	switch v := value.(type) {
+	case float64:
+		return floatVM(v)
+	case int64:
+		return intVM(v)
+	}
+	return vmValue{obj: value}
+}
+
+func (v vmValue) float() float64 {
+	return math.Float64frombits(uint64(v.num))
+}
+
+func (v vmValue) value() interface{} {
+	switch v.kind {
+	case vmFloat:
+		return v.float()
+	case vmInt:
+		return v.num
+	}
+	return v.obj
+}
+
+func (v vmValue) isTruthy() bool {
+	return v.kind != vmObj || isTruthy(v.obj)
+}
+
+// VM executes compiled bytecode on a value stack. It shares its globals with
+// the tree-walking Interpreter, which also supplies the native functions'
+// context, so values defined by either backend are visible to the other.
+type VM struct {
+	interpreter  *Interpreter
+	stack        []vmValue
+	sp           int
+	frames       []CallFrame
+	openUpvalues *Upvalue
+}
+
+func NewVM(interpreter *Interpreter) *VM {
+	return &VM{
+		interpreter: interpreter,
+		stack:       make([]vmValue, 256),
+		frames:      make([]CallFrame, 0, 64),
+	}
+}
+
+// interpret runs a compiled script and returns the value it returns, which
+// is the value of a trailing expression statement.
+func (vm *VM) interpret(script *CompiledFunction) (interface{}, error) {
+	closure := NewClosure(vm, script)
+	vm.push(vmValue{obj: closure})
+	err := vm.call(closure, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := vm.run(0)
+	if err != nil {
+		vm.reset()
+		return nil, err
+	}
+	return value.value(), nil
+}
+
+func (vm *VM) reset() {
+	vm.sp = 0
+	vm.frames = vm.frames[:0]
+	vm.openUpvalues = nil
+}
+
+func (vm *VM) push(value vmValue) {
+	if vm.sp == len(vm.stack) {
+		grown := make([]vmValue, len(vm.stack)*2)
+		copy(grown, vm.stack)
+		vm.stack = grown
+	}
+	vm.stack[vm.sp] = value
+	vm.sp++
+}
+
+// pop leaves the value in its slot; the next push overwrites it.
+func (vm *VM) pop() vmValue {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
+
+func (vm *VM) peek(distance int) vmValue {
+	return vm.stack[vm.sp-1-distance]
+}
+
+// callFromGo runs closure to completion on behalf of Go code such as a
+// native function or Engine.Call. receiver, when set, fills slot zero.
+func (vm *VM) callFromGo(closure *Closure, receiver *PyroInstance, arguments []interface{}) (interface{}, error) {
+	base := vm.sp
+	if receiver != nil {
+		vm.push(vmValue{obj: receiver})
+	} else {
+		vm.push(vmValue{obj: closure})
+	}
+	for _, argument := range arguments {
+		vm.push(toVM(argument))
+	}
+
+	stopDepth := len(vm.frames)
+	err := vm.call(closure, len(arguments))
+	if err == nil {
+		var value vmValue
+		value, err = vm.run(stopDepth)
+		if err == nil {
+			return value.value(), nil
+		}
+	}
+
+	vm.closeUpvalues(base)
+	vm.frames = vm.frames[:stopDepth]
+	vm.sp = base
+	if _, isRunTime := err.(RunTimeError); !isRunTime {
+		if _, isExit := err.(ExitError); !isExit {
+			return nil, NativeError{Message: err.Error()}
+		}
+	}
+	return nil, err
+}
+
+func (vm *VM) call(closure *Closure, argCount int) error {
+	if argCount != closure.Function.Arity {
+		return errors.New("Expected " + strconv.Itoa(closure.Function.Arity) + " arguments but got " + strconv.Itoa(argCount))
+	}
+	if len(vm.frames) >= maxFrames {
+		return errors.New("Stack overflow.")
+	}
+
+	vm.frames = append(vm.frames, CallFrame{
+		Closure: closure,
+		IP:      0,
+		Base:    vm.sp - argCount - 1,
+	})
+	return nil
+}
+
+func (vm *VM) callValue(callee vmValue, argCount int) error {
+	switch c := callee.obj.(type) {
+	case *Closure:
+		return vm.call(c, argCount)
+	case *BoundMethod:
+		vm.stack[vm.sp-argCount-1] = vmValue{obj: c.Receiver}
+		return vm.call(c.Method, argCount)
+	case *PyroClass:
+		instance := NewPyroInstance(c)
+		vm.stack[vm.sp-argCount-1] = vmValue{obj: instance}
+
+		initializer, exists := c.findMethod("init")
+		if !exists {
+			if argCount != 0 {
+				return errors.New("Expected 0 arguments but got " + strconv.Itoa(argCount))
+			}
+			return nil
+		}
+		if closure, isClosure := initializer.(*Closure); isClosure {
+			return vm.call(closure, argCount)
+		}
+		_, err := vm.callNative(initializer.bind(instance), argCount)
+		if err != nil {
+			return err
+		}
+		vm.push(vmValue{obj: instance})
+		return nil
+	case Callable:
+		value, err := vm.callNative(c, argCount)
+		if err != nil {
+			return err
+		}
+		vm.push(toVM(value))
+		return nil
+	}
+	return errors.New("Can only call functions and classes.")
+}
+
+// callNative calls a Callable implemented outside the VM, popping the callee
+// and its arguments.
+func (vm *VM) callNative(function Callable, argCount int) (interface{}, error) {
+	if function.Arity() >= 0 && argCount != function.Arity() {
+		return nil, errors.New("Expected " + strconv.Itoa(function.Arity()) + " arguments but got " + strconv.Itoa(argCount))
+	}
+
+	arguments := make([]interface{}, argCount)
+	for i := range arguments {
+		arguments[i] = vm.stack[vm.sp-argCount+i].value()
+	}
+	vm.sp -= argCount + 1
+	return function.Call(vm.interpreter, arguments)
+}
+
+func (vm *VM) captureUpvalue(slot int) *Upvalue {
+	var previous *Upvalue
+	upvalue := vm.openUpvalues
+	for upvalue != nil && upvalue.Slot > slot {
+		previous = upvalue
+		upvalue = upvalue.Next
+	}
+	if upvalue != nil && upvalue.Slot == slot {
+		return upvalue
+	}
+
+	created := &Upvalue{vm: vm, Slot: slot, Open: true, Next: upvalue}
+	if previous == nil {
+		vm.openUpvalues = created
+	} else {
+		previous.Next = created
+	}
+	return created
+}
+
+// closeUpvalues moves every captured variable at or above slot off the stack.
+func (vm *VM) closeUpvalues(slot int) {
+	for vm.openUpvalues != nil && vm.openUpvalues.Slot >= slot {
+		upvalue := vm.openUpvalues
+		upvalue.Closed = vm.stack[upvalue.Slot]
+		upvalue.Open = false
+		vm.openUpvalues = upvalue.Next
+	}
+}
+
+// runtimeError locates err at the instruction starting at offset in the
+// current frame. Errors that already carry a location pass through.
+func (vm *VM) runtimeError(err error, offset int) error {
+	switch err.(type) {
+	case RunTimeError, ExitError:
+		return err
+	}
+	return NewRunTimeError(vm.tokenAt(0, offset), err.Error())
+}
+
+func (vm *VM) tokenAt(tt TokenType, offset int) Token {
+	span := vm.frames[len(vm.frames)-1].Closure.Function.Chunk.Spans[offset]
+	return Token{Type: tt, Line: span.Line, Span: span}
+}
+
+// run executes instructions until the frame count drops to stopDepth and
+// returns the value the last frame returned.
+func (vm *VM) run(stopDepth int) (vmValue, error) {
+	frame := &vm.frames[len(vm.frames)-1]
+	code := frame.Closure.Function.Chunk.Code
+	values := frame.Closure.Function.Chunk.constantValues()
+	ip := frame.IP
+
+	for {
+		start := ip
+		op := OpCode(code[ip])
+		ip++
+
+		switch op {
+		case OP_CONSTANT:
+			vm.push(values[int(code[ip])<<8|int(code[ip+1])])
+			ip += 2
+		case OP_NIL:
+			vm.push(vmValue{})
+		case OP_TRUE:
+			vm.push(vmValue{obj: true})
+		case OP_FALSE:
+			vm.push(vmValue{obj: false})
+		case OP_POP:
+			vm.sp--
+		case OP_DUP:
+			count := int(code[ip])
+			ip++
+			for i := 0; i < count; i++ {
+				vm.push(vm.stack[vm.sp-count])
+			}
+		case OP_BURY:
+			depth := int(code[ip])
+			ip++
+			top := vm.stack[vm.sp-1]
+			copy(vm.stack[vm.sp-depth:vm.sp], vm.stack[vm.sp-depth-1:vm.sp-1])
+			vm.stack[vm.sp-depth-1] = top
+		case OP_GET_LOCAL:
+			vm.push(vm.stack[frame.Base+int(code[ip])])
+			ip++
+		case OP_SET_LOCAL:
+			vm.stack[frame.Base+int(code[ip])] = vm.stack[vm.sp-1]
+			ip++
+		case OP_STORE_LOCAL:
+			vm.sp--
+			vm.stack[frame.Base+int(code[ip])] = vm.stack[vm.sp]
+			ip++
+		case OP_GET_GLOBAL:
+			global := values[int(code[ip])<<8|int(code[ip+1])].obj.(*Global)
+			ip += 2
+			if !global.Defined {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Undefined variable '"+global.Name+"'."), start)
+			}
+			vm.push(global.Value)
+		case OP_DEFINE_GLOBAL:
+			global := values[int(code[ip])<<8|int(code[ip+1])].obj.(*Global)
+			ip += 2
+			global.Value, global.Defined = vm.pop(), true
+		case OP_SET_GLOBAL, OP_STORE_GLOBAL:
+			global := values[int(code[ip])<<8|int(code[ip+1])].obj.(*Global)
+			ip += 2
+			if !global.Defined {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Undefined variable '"+global.Name+"'."), start)
+			}
+			global.Value = vm.peek(0)
+			if op == OP_STORE_GLOBAL {
+				vm.sp--
+			}
+		case OP_GET_UPVALUE:
+			vm.push(frame.Closure.Upvalues[code[ip]].get())
+			ip++
+		case OP_SET_UPVALUE:
+			frame.Closure.Upvalues[code[ip]].set(vm.peek(0))
+			ip++
+		case OP_GET_PROPERTY:
+			name := values[int(code[ip])<<8|int(code[ip+1])].obj.(string)
+			ip += 2
+			instance, isInstance := vm.peek(0).obj.(*PyroInstance)
+			if !isInstance {
+				if collection, isCollection := vm.peek(0).obj.(collection); isCollection {
+					if method, exists := collection.method(name); exists {
+						vm.stack[vm.sp-1] = vmValue{obj: method}
+						break
+					}
+					frame.IP = ip
+					return vmValue{}, vm.runtimeError(errors.New("Undefined property '"+name+"'."), start)
+				}
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Only instances have properties."), start)
+			}
+			value, exists := instance.lookup(name)
+			if !exists {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Undefined property '"+name+"'."), start)
+			}
+			vm.stack[vm.sp-1] = toVM(value)
+		case OP_SET_PROPERTY:
+			name := values[int(code[ip])<<8|int(code[ip+1])].obj.(string)
+			ip += 2
+			instance, isInstance := vm.peek(1).obj.(*PyroInstance)
+			if !isInstance {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Only instances have fields."), start)
+			}
+			value := vm.pop()
+			instance.Fields[name] = value.value()
+			vm.stack[vm.sp-1] = value
+		case OP_GET_SUPER:
+			name := values[int(code[ip])<<8|int(code[ip+1])].obj.(string)
+			ip += 2
+			superclass := vm.pop().obj.(*PyroClass)
+			receiver := vm.pop().obj.(*PyroInstance)
+			method, exists := superclass.findMethod(name)
+			if !exists {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Undefined property '"+name+"'."), start)
+			}
+			vm.push(vmValue{obj: method.bind(receiver)})
+		case OP_LIST:
+			count := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			elements := make([]interface{}, count)
+			for i := range elements {
+				elements[i] = vm.stack[vm.sp-count+i].value()
+			}
+			vm.sp -= count
+			vm.push(vmValue{obj: NewPyroList(elements)})
+		case OP_INTERPOLATE:
+			count := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			var text strings.Builder
+			for _, part := range vm.stack[vm.sp-count : vm.sp] {
+				text.WriteString(stringify(part.value()))
+			}
+			vm.sp -= count
+			vm.push(vmValue{obj: text.String()})
+		case OP_MAP:
+			count := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			keys := make([]interface{}, count)
+			values := make([]interface{}, count)
+			for i := range keys {
+				keys[i] = vm.stack[vm.sp-2*count+2*i].value()
+				values[i] = vm.stack[vm.sp-2*count+2*i+1].value()
+			}
+			pm, err := buildMap(vm.tokenAt(LBRACE, start), keys, values)
+			if err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+			vm.sp -= 2 * count
+			vm.push(vmValue{obj: pm})
+		case OP_GET_INDEX:
+			value, err := getIndex(vm.tokenAt(RBRACKET, start), vm.peek(1).value(), vm.peek(0).value())
+			if err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+			vm.sp--
+			vm.stack[vm.sp-1] = toVM(value)
+		case OP_SET_INDEX:
+			value := vm.peek(0)
+			if err := setIndex(vm.tokenAt(RBRACKET, start), vm.peek(2).value(), vm.peek(1).value(), value.value()); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+			vm.sp -= 2
+			vm.stack[vm.sp-1] = value
+		case OP_UNARY:
+			operator := TokenType(code[ip])
+			ip++
+			operand := &vm.stack[vm.sp-1]
+			if operand.kind == vmFloat && operator == MINUS {
+				*operand = floatVM(-operand.float())
+				break
+			}
+			if operand.kind == vmInt && operator == MINUS && operand.num != math.MinInt64 {
+				operand.num = -operand.num
+				break
+			}
+			value, err := unaryOp(vm.tokenAt(operator, start), operand.value())
+			if err != nil {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(err, start)
+			}
+			*operand = toVM(value)
+		case OP_BINARY:
+			operator := TokenType(code[ip])
+			ip++
+			if err := vm.binary(operator, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_BINARY_CONSTANT:
+			operator := TokenType(code[ip])
+			right := values[int(code[ip+1])<<8|int(code[ip+2])]
+			ip += 3
+			if !fastArithmetic(operator, &vm.stack[vm.sp-1], right) {
+				vm.push(right)
+				if err := vm.binary(operator, start); err != nil {
+					frame.IP = ip
+					return vmValue{}, err
+				}
+			}
+		case OP_ADD:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = floatVM(left.float() + right.float())
+				vm.sp--
+			} else if result, fits := smallArithmetic(PLUS, left.num, right.num); left.kind == vmInt && right.kind == vmInt && fits {
+				left.num = result
+				vm.sp--
+			} else if err := vm.binary(PLUS, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_SUBTRACT:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = floatVM(left.float() - right.float())
+				vm.sp--
+			} else if result, fits := smallArithmetic(MINUS, left.num, right.num); left.kind == vmInt && right.kind == vmInt && fits {
+				left.num = result
+				vm.sp--
+			} else if err := vm.binary(MINUS, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_MULTIPLY:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = floatVM(left.float() * right.float())
+				vm.sp--
+			} else if result, fits := smallArithmetic(STAR, left.num, right.num); left.kind == vmInt && right.kind == vmInt && fits {
+				left.num = result
+				vm.sp--
+			} else if err := vm.binary(STAR, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_DIVIDE:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = floatVM(left.float() / right.float())
+				vm.sp--
+			} else if err := vm.binary(SLASH, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_MODULO:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = floatVM(floatMod(left.float(), right.float()))
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt && right.num != 0 {
+				left.num = floorMod(left.num, right.num)
+				vm.sp--
+			} else if err := vm.binary(MOD, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_EQUAL:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = vmValue{obj: left.float() == right.float()}
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt {
+				*left = vmValue{obj: left.num == right.num}
+				vm.sp--
+			} else if err := vm.binary(EQEQ, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_NOT_EQUAL:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = vmValue{obj: left.float() != right.float()}
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt {
+				*left = vmValue{obj: left.num != right.num}
+				vm.sp--
+			} else if err := vm.binary(NE, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_GREATER:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = vmValue{obj: left.float() > right.float()}
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt {
+				*left = vmValue{obj: left.num > right.num}
+				vm.sp--
+			} else if err := vm.binary(GT, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_GREATER_EQUAL:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = vmValue{obj: left.float() >= right.float()}
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt {
+				*left = vmValue{obj: left.num >= right.num}
+				vm.sp--
+			} else if err := vm.binary(GE, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_LESS:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = vmValue{obj: left.float() < right.float()}
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt {
+				*left = vmValue{obj: left.num < right.num}
+				vm.sp--
+			} else if err := vm.binary(LT, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_LESS_EQUAL:
+			if left, right := &vm.stack[vm.sp-2], &vm.stack[vm.sp-1]; left.kind == vmFloat && right.kind == vmFloat {
+				*left = vmValue{obj: left.float() <= right.float()}
+				vm.sp--
+			} else if left.kind == vmInt && right.kind == vmInt {
+				*left = vmValue{obj: left.num <= right.num}
+				vm.sp--
+			} else if err := vm.binary(LE, start); err != nil {
+				frame.IP = ip
+				return vmValue{}, err
+			}
+		case OP_PRINT:
+			fmt.Fprintln(vm.interpreter.Stdout, stringify(vm.pop().value()))
+		case OP_JUMP:
+			ip += 2 + (int(code[ip])<<8 | int(code[ip+1]))
+		case OP_JUMP_IF_FALSE:
+			offset := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			if !vm.stack[vm.sp-1].isTruthy() {
+				ip += offset
+			}
+		case OP_POP_JUMP_IF_FALSE:
+			offset := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			vm.sp--
+			if !vm.stack[vm.sp].isTruthy() {
+				ip += offset
+			}
+		case OP_JUMP_IF_NOT_NIL:
+			offset := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			if top := vm.stack[vm.sp-1]; top.kind != vmObj || top.obj != nil {
+				ip += offset
+			}
+		case OP_COMPARE_JUMP, OP_COMPARE_CONSTANT_JUMP:
+			operator := TokenType(code[ip])
+			ip++
+			if op == OP_COMPARE_CONSTANT_JUMP {
+				vm.push(values[int(code[ip])<<8|int(code[ip+1])])
+				ip += 2
+			}
+			offset := int(code[ip])<<8 | int(code[ip+1])
+			ip += 2
+			holds, isFast := fastCompare(operator, vm.stack[vm.sp-2], vm.stack[vm.sp-1])
+			if !isFast {
+				if err := vm.binary(operator, start); err != nil {
+					frame.IP = ip
+					return vmValue{}, err
+				}
+				holds = vm.stack[vm.sp-1].isTruthy()
+				vm.sp++
+			}
+			vm.sp -= 2
+			if !holds {
+				ip += offset
+			}
+		case OP_LOOP:
+			ip += 2
+			ip -= int(code[ip-2])<<8 | int(code[ip-1])
+		case OP_CALL:
+			argCount := int(code[ip])
+			ip++
+			frame.IP = ip
+			err := vm.callValue(vm.peek(argCount), argCount)
+			if err != nil {
+				return vmValue{}, vm.runtimeError(err, start)
+			}
+			frame = &vm.frames[len(vm.frames)-1]
+			code = frame.Closure.Function.Chunk.Code
+			values = frame.Closure.Function.Chunk.constantValues()
+			ip = frame.IP
+		case OP_CLOSURE:
+			function := values[int(code[ip])<<8|int(code[ip+1])].obj.(*CompiledFunction)
+			ip += 2
+			closure := NewClosure(vm, function)
+			for i := range closure.Upvalues {
+				isLocal := code[ip] == 1
+				index := int(code[ip+1])
+				ip += 2
+				if isLocal {
+					closure.Upvalues[i] = vm.captureUpvalue(frame.Base + index)
+				} else {
+					closure.Upvalues[i] = frame.Closure.Upvalues[index]
+				}
+			}
+			vm.push(vmValue{obj: closure})
+		case OP_CLOSE_UPVALUE:
+			vm.closeUpvalues(vm.sp - 1)
+			vm.sp--
+		case OP_RETURN:
+			result := vm.pop()
+			vm.closeUpvalues(frame.Base)
+			vm.sp = frame.Base
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == stopDepth {
+				return result, nil
+			}
+			vm.push(result)
+			frame = &vm.frames[len(vm.frames)-1]
+			code = frame.Closure.Function.Chunk.Code
+			values = frame.Closure.Function.Chunk.constantValues()
+			ip = frame.IP
+		case OP_CLASS:
+			name := values[int(code[ip])<<8|int(code[ip+1])].obj.(string)
+			ip += 2
+			vm.push(vmValue{obj: NewPyroClass(name, nil, make(map[string]Method))})
+		case OP_INHERIT:
+			superclass, isClass := vm.peek(1).obj.(*PyroClass)
+			if !isClass {
+				frame.IP = ip
+				return vmValue{}, vm.runtimeError(errors.New("Superclass must be a class."), start)
+			}
+			vm.peek(0).obj.(*PyroClass).Superclass = superclass
+			vm.sp--
+		case OP_METHOD:
+			name := values[int(code[ip])<<8|int(code[ip+1])].obj.(string)
+			ip += 2
+			vm.peek(1).obj.(*PyroClass).Methods[name] = vm.peek(0).obj.(*Closure)
+			vm.sp--
+		default:
+			frame.IP = ip
+			return vmValue{}, vm.runtimeError(errors.New("Unknown opcode "+op.String()+"."), start)
+		}
+	}
+}
+
+// fastArithmetic applies an arithmetic operator to two ints or two floats
+// in place of left. It reports false, leaving left alone, for anything else
+// and for results that need a *big.Int.
+func fastArithmetic(operator TokenType, left *vmValue, right vmValue) bool {
+	if left.kind == vmFloat && right.kind == vmFloat {
+		*left = floatVM(floatArithmetic(operator, left.float(), right.float()))
+		return true
+	}
+	if left.kind != vmInt || right.kind != vmInt {
+		return false
+	}
+	switch operator {
+	case SLASH:
+		return false
+	case MOD:
+		if right.num == 0 {
+			return false
+		}
+		left.num = floorMod(left.num, right.num)
+		return true
+	}
+	result, fits := smallArithmetic(operator, left.num, right.num)
+	if fits {
+		left.num = result
+	}
+	return fits
+}
+
+// fastCompare applies a comparison to two ints or two floats. It reports
+// false as its second result for anything else.
+func fastCompare(operator TokenType, left vmValue, right vmValue) (bool, bool) {
+	if left.kind != right.kind || left.kind == vmObj {
+		return false, false
+	}
+	if left.kind == vmFloat {
+		l, r := left.float(), right.float()
+		switch operator {
+		case EQEQ:
+			return l == r, true
+		case NE:
+			return l != r, true
+		case GT:
+			return l > r, true
+		case GE:
+			return l >= r, true
+		case LT:
+			return l < r, true
+		}
+		return l <= r, true
+	}
+	l, r := left.num, right.num
+	switch operator {
+	case EQEQ:
+		return l == r, true
+	case NE:
+		return l != r, true
+	case GT:
+		return l > r, true
+	case GE:
+		return l >= r, true
+	case LT:
+		return l < r, true
+	}
+	return l <= r, true
+}
+
+// binary applies operator to the top two stack values through the
+// tree-walker's binaryOp, which covers every operand type.
+func (vm *VM) binary(operator TokenType, offset int) error {
+	right := vm.pop()
+	left := vm.pop()
+	value, err := binaryOp(vm.tokenAt(operator, offset), left.value(), right.value(), vm.interpreter.decimals)
+	if err != nil {
+		return err
+	}
+	vm.push(toVM(value))
+	return nil
+}
@@ -0,0 +1,106 @@
+package pyro
+
+// CompiledFunction is the bytecode for one function body.
+type CompiledFunction struct {
+	Name         string
+	Arity        int
+	UpvalueCount int
+	Chunk        *Chunk
+}
+
+func NewCompiledFunction(name string) *CompiledFunction {
+	return &CompiledFunction{
+		Name:  name,
+		Chunk: NewChunk(),
+	}
+}
+
+func (cf *CompiledFunction) toString() string {
+	if cf.Name == "" {
+		return "<script>"
+	}
+	return "<fn " + cf.Name + ">"
+}
+
+// Upvalue is a variable captured by a closure. While the variable is still
+// on the VM stack it refers to the slot; once the slot goes out of scope the
+// value moves into Closed.
+type Upvalue struct {
+	vm     *VM
+	Slot   int
+	Open   bool
+	Closed vmValue
+	Next   *Upvalue
+}
+
+func (u *Upvalue) get() vmValue {
+	if u.Open {
+		return u.vm.stack[u.Slot]
+	}
+	return u.Closed
+}
+
+func (u *Upvalue) set(value vmValue) {
+	if u.Open {
+		u.vm.stack[u.Slot] = value
+		return
+	}
+	u.Closed = value
+}
+
+// Closure is a CompiledFunction together with its captured upvalues. It is
+// what scripts see as a function value when running on the VM.
+type Closure struct {
+	Function *CompiledFunction
+	Upvalues []*Upvalue
+	vm       *VM
+}
+
+func NewClosure(vm *VM, function *CompiledFunction) *Closure {
+	return &Closure{
+		Function: function,
+		Upvalues: make([]*Upvalue, function.UpvalueCount),
+		vm:       vm,
+	}
+}
+
+func (c *Closure) Arity() int {
+	return c.Function.Arity
+}
+
+func (c *Closure) Call(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+	return c.vm.callFromGo(c, nil, arguments)
+}
+
+func (c *Closure) bind(instance *PyroInstance) Callable {
+	return NewBoundMethod(instance, c)
+}
+
+func (c *Closure) toString() string {
+	return c.Function.toString()
+}
+
+// BoundMethod is a Closure whose receiver slot is fixed to an instance.
+type BoundMethod struct {
+	Receiver *PyroInstance
+	Method   *Closure
+}
+
+func NewBoundMethod(receiver *PyroInstance, method *Closure) *BoundMethod {
+	return &BoundMethod{
+		Receiver: receiver,
+		Method:   method,
+	}
+}
+
+func (bm *BoundMethod) Arity() int {
+	return bm.Method.Arity()
+}
+
+func (bm *BoundMethod) Call(_ *Interpreter, arguments []interface{}) (interface{}, error) {
+	return bm.Method.vm.callFromGo(bm.Method, bm.Receiver, arguments)
+}
+
+func (bm *BoundMethod) toString() string {
+	return bm.Method.toString()
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"interpreters/pkg/pyro"
+)
+
+const replHelp = `Enter Pyro statements; input continues until brackets and strings close.
+The value of a bare expression is printed, and a trailing ';' may be omitted.
+
+  :help         show this message
+  :env          list global variables
+  :load <file>  run a file in this session
+  :reset        discard all definitions
+  :ast <code>   print the syntax tree of code
+  :quit         leave the REPL`
+
+// Repl is an interactive session that keeps one engine alive across inputs.
+type Repl struct {
+	Engine *pyro.Engine
+	Input  *bufio.Reader
+	Output io.Writer
+	UseVM  bool
+}
+
+func NewRepl(input io.Reader, output io.Writer, useVM bool) *Repl {
+	repl := &Repl{
+		Input:  bufio.NewReader(input),
+		Output: output,
+		UseVM:  useVM,
+	}
+	repl.reset()
+	return repl
+}
+
+func (r *Repl) reset() {
+	r.Engine = pyro.NewEngine()
+	r.Engine.UseVM(r.UseVM)
+	r.Engine.SetStdin(r.Input)
+	r.Engine.SetStdout(r.Output)
+}
+
+func (r *Repl) run() {
+	for {
+		source, err := r.readInput()
+		if err != nil {
+			fmt.Fprintln(r.Output)
+			return
+		}
+
+		trimmed := strings.TrimSpace(source)
+		if trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, ":") {
+			if quit := r.command(trimmed); quit {
+				return
+			}
+			continue
+		}
+
+		r.eval(trimmed)
+	}
+}
+
+// readInput reads lines until the accumulated text is complete.
+func (r *Repl) readInput() (string, error) {
+	fmt.Fprint(r.Output, "> ")
+
+	var source strings.Builder
+	for {
+		line, err := r.Input.ReadString('\n')
+		source.WriteString(line)
+		if err != nil {
+			if source.Len() == 0 {
+				return "", err
+			}
+			return source.String(), nil
+		}
+
+		text := source.String()
+		if strings.HasPrefix(strings.TrimSpace(text), ":") || !pyro.IsIncomplete(text) {
+			return text, nil
+		}
+		fmt.Fprint(r.Output, "... ")
+	}
+}
+
+func (r *Repl) eval(source string) {
+	value, err := r.Engine.EvalNamed("<stdin>", pyro.TerminateStatement(source))
+	if exitErr, isExit := err.(pyro.ExitError); isExit {
+		os.Exit(exitErr.Code)
+	}
+	if err == nil && value != nil {
+		fmt.Fprintln(r.Output, pyro.Stringify(value))
+	}
+}
+
+// command runs a meta-command and reports whether the session should end.
+func (r *Repl) command(line string) bool {
+	name, argument, _ := strings.Cut(line, " ")
+	argument = strings.TrimSpace(argument)
+
+	switch name {
+	case ":help":
+		fmt.Fprintln(r.Output, replHelp)
+	case ":env":
+		for _, global := range r.Engine.Globals() {
+			value, _ := r.Engine.Lookup(global)
+			fmt.Fprintf(r.Output, "%s = %s\n", global, pyro.Stringify(value))
+		}
+	case ":load":
+		if argument == "" {
+			fmt.Fprintln(r.Output, "Usage: :load <file>")
+			break
+		}
+		fileBytes, err := os.ReadFile(argument)
+		if err != nil {
+			fmt.Fprintln(r.Output, "Error opening file:", err)
+			break
+		}
+		if pyro.IsCompiled(fileBytes) {
+			_, err = r.Engine.EvalCompiled(fileBytes)
+		} else {
+			_, err = r.Engine.EvalNamed(argument, string(fileBytes))
+		}
+		if _, isFormat := err.(pyro.FormatError); isFormat {
+			fmt.Fprintln(r.Output, err)
+		}
+		if exitErr, isExit := err.(pyro.ExitError); isExit {
+			os.Exit(exitErr.Code)
+		}
+	case ":reset":
+		r.reset()
+		fmt.Fprintln(r.Output, "Session reset.")
+	case ":ast":
+		if argument == "" {
+			fmt.Fprintln(r.Output, "Usage: :ast <code>")
+			break
+		}
+		if !strings.HasSuffix(argument, ";") && !strings.HasSuffix(argument, "}") {
+			argument += ";"
+		}
+		tree, err := pyro.FormatAST(argument)
+		if err != nil {
+			fmt.Fprintln(r.Output, err)
+			break
+		}
+		fmt.Fprintln(r.Output, tree)
+	case ":quit", ":q", ":exit":
+		return true
+	default:
+		fmt.Fprintln(r.Output, "Unknown command "+name+"; try :help")
+	}
+	return false
+}